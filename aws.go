@@ -0,0 +1,89 @@
+package corn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// awsWeekDays EventBridge 星期的取值范围，1-7 分别表示周日到周六
+var awsWeekDays = bounds{min: 1, max: 7, cycle: 7, offset: 1, names: map[string]uint64{
+	"sun": 1, "mon": 2, "tue": 3, "wed": 4, "thu": 5, "fri": 6, "sat": 7,
+}}
+
+// awsRateUnits rate() 支持的时间单位
+var awsRateUnits = map[string]time.Duration{
+	"minute": time.Minute, "minutes": time.Minute,
+	"hour": time.Hour, "hours": time.Hour,
+	"day": 24 * time.Hour, "days": 24 * time.Hour,
+}
+
+// isAWSExpr 是否为 cron(...) 或 rate(...) 表达式
+func isAWSExpr(tokens []field) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	text := strings.ToLower(tokens[0].text)
+	return strings.HasPrefix(text, "cron(") || strings.HasPrefix(text, "rate(")
+}
+
+// parseAWS 解析 EventBridge 的 cron(...) 或 rate(...) 表达式
+func (p *Parser) parseAWS(spec string, tokens []field, loc *time.Location) (Scheduler, error) {
+	name := strings.ToLower(tokens[0].text[:4])
+
+	// 去掉括号，保留各字段在表达式中的位置
+	last := tokens[len(tokens)-1]
+	if !strings.HasSuffix(last.text, ")") {
+		return nil, newParseError(spec, last, -1, "missing )")
+	}
+	params := make([]field, 0, len(tokens))
+	for i, tok := range tokens {
+		if i == 0 {
+			tok.text, tok.offset = tok.text[5:], tok.offset+5
+		}
+		if i == len(tokens)-1 {
+			tok.text = tok.text[:len(tok.text)-1]
+		}
+		if tok.text != "" {
+			tok.index = len(params)
+			params = append(params, tok)
+		}
+	}
+
+	if name == "rate" {
+		return parseAWSRate(spec, params)
+	}
+
+	if len(params) != 6 {
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: fmt.Sprintf("cron() expects 6 fields, got %d", len(params))}
+	}
+	if (params[2].text == "?") == (params[4].text == "?") {
+		return nil, newParseError(spec, params[2], 3, "one of day-of-month and day-of-week must be ?")
+	}
+
+	// cron() 没有秒字段
+	params = append([]field{{text: "0", offset: -1, index: -1}}, params...)
+	ts, err := p.parseFields(spec, params, awsWeekDays)
+	if err != nil {
+		return nil, err
+	}
+	ts.loc = loc
+	return ts, nil
+}
+
+// parseAWSRate 解析 rate(n 单位) 的参数
+func parseAWSRate(spec string, params []field) (Scheduler, error) {
+	if len(params) != 2 {
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: fmt.Sprintf("rate() expects 2 fields, got %d", len(params))}
+	}
+	n, err := strconv.Atoi(params[0].text)
+	if err != nil || n <= 0 {
+		return nil, newParseError(spec, params[0], -1, "invalid rate value")
+	}
+	unit, ok := awsRateUnits[strings.ToLower(params[1].text)]
+	if !ok {
+		return nil, newParseError(spec, params[1], -1, "invalid rate unit")
+	}
+	return &DurationSchedule{start: time.Now(), frequency: time.Duration(n) * unit}, nil
+}
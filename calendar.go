@@ -0,0 +1,255 @@
+package corn
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarShorthands systemd 日历表达式的简写
+var calendarShorthands = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+}
+
+// calendarWeekNames systemd 星期的全称
+var calendarWeekNames = map[string]string{
+	"sunday": "sun", "monday": "mon", "tuesday": "tue", "wednesday": "wed",
+	"thursday": "thu", "friday": "fri", "saturday": "sat",
+}
+
+// ParseCalendar 解析 systemd 定时器 OnCalendar= 格式的日历表达式，格式如下:
+//  [星期] [[年-]月-日] [时:分[:秒]] [时区]
+// 星期: 英文全称或缩写(不区分大小写)，用 ',' 分割，用 '..' 表示范围，如 Mon..Fri,Sun
+// 日期: 年、月、日都可以使用 *、','、'..' 以及 '/n'(从起始值开始每隔 n 执行)，省略年时为 *，如 *-*-01
+//       月与日之间用 '~' 分割时表示每月倒数第几天，如 *-02~03 表示 2 月倒数第 3 天，*-05~07/1 表示 5 月的最后 7 天
+// 时间: 时、分、秒的写法同日期，省略秒时为 00，如 *:0/15
+// 时区: time.LoadLocation 支持的时区名称，如 UTC、Asia/Shanghai，省略时使用 time.Local
+//
+// 省略日期时为 *-*-*，省略时间时为 00:00:00，同时指定星期和日期时需要同时满足
+// 也支持 minutely、hourly、daily、weekly、monthly、yearly(或 annually)、quarterly、semiannually 简写，
+// 简写后面可以指定时区，如 weekly Asia/Shanghai
+//
+// 举例如下:
+//  Mon..Fri *-*-* 09:00:00               工作日 9:00 执行
+//  *-*-01 00:00                          每月 1 日 0:00 执行
+//  Sat,Sun 08:05:40                      周末 8:05:40 执行
+//  Mon *-05~07/1 12:00                   5 月最后一个周一 12:00 执行
+//  2027-03-05 05:40 UTC                  UTC 时间 2027 年 3 月 5 日 5:40 执行
+//
+// 年的取值范围与 Parse 相同(StartYear 到 StartYear+63)，秒不支持小数
+// 解析失败时返回 *ParseError
+func ParseCalendar(spec string) (Scheduler, error) {
+	tokens := splitFields(spec)
+	if len(tokens) == 0 {
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: "empty calendar expression"}
+	}
+
+	// 简写展开后重新按字段解析，错误位置指向简写
+	if expr, ok := calendarShorthands[strings.ToLower(tokens[0].text)]; ok {
+		short := tokens[0]
+		expanded := splitFields(expr)
+		for i := range expanded {
+			expanded[i].offset, expanded[i].index = short.offset, short.index
+		}
+		tokens = append(expanded, tokens[1:]...)
+	}
+
+	ts := &TimeSchedule{
+		second:  1,
+		min:     1,
+		hour:    1,
+		day:     RangeDay,
+		month:   RangeMonth,
+		weekDay: RangeWeekDay,
+		year:    RangeYear,
+		loc:     time.Local,
+	}
+
+	// 星期
+	if isCalendarName(tokens[0].text) {
+		w, err := parseCalendarWeekDays(tokens[0].text)
+		if err != nil {
+			return nil, newParseError(spec, tokens[0], 5, err.Error())
+		}
+		ts.weekDay = w
+		tokens = tokens[1:]
+	}
+
+	// 时区
+	if n := len(tokens); n > 0 && isCalendarName(tokens[n-1].text) {
+		loc, err := time.LoadLocation(tokens[n-1].text)
+		if err != nil {
+			return nil, newParseError(spec, tokens[n-1], -1, "unknown time zone")
+		}
+		ts.loc = loc
+		tokens = tokens[:n-1]
+	}
+
+	var hasDate, hasTime bool
+	for _, tok := range tokens {
+		var err error
+		switch {
+		case strings.Contains(tok.text, ":") && !hasTime:
+			hasTime = true
+			err = ts.parseCalendarTime(spec, tok)
+		case !strings.Contains(tok.text, ":") && !hasDate && !hasTime:
+			hasDate = true
+			err = ts.parseCalendarDate(spec, tok)
+		default:
+			err = newParseError(spec, tok, -1, "unexpected token")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts.amend()
+	return ts, nil
+}
+
+// isCalendarName 以字母开头的字段为星期或时区
+func isCalendarName(text string) bool {
+	return text != "" && (text[0] >= 'a' && text[0] <= 'z' || text[0] >= 'A' && text[0] <= 'Z')
+}
+
+// parseCalendarWeekDays 解析星期列表，如 Mon..Fri,Sun，允许以 ',' 结尾
+func parseCalendarWeekDays(text string) (uint64, error) {
+	var w uint64
+	for _, item := range strings.Split(strings.TrimSuffix(text, ","), ",") {
+		names := strings.Split(item, "..")
+		if len(names) > 2 {
+			return 0, errRange
+		}
+		for i, name := range names {
+			name = strings.ToLower(name)
+			if short, ok := calendarWeekNames[name]; ok {
+				name = short
+			}
+			if _, ok := weekNames[name]; !ok {
+				return 0, errValue
+			}
+			names[i] = name
+		}
+		v, err := parse(strings.Join(names, "-"), weekDays)
+		if err != nil {
+			return 0, err
+		}
+		w |= v
+	}
+	return w, nil
+}
+
+// parseCalendarField 解析日期或时间的一个部分，如 1,3、1..5、*/2、01/2
+func parseCalendarField(text string, b bounds) (uint64, error) {
+	var v uint64
+	for _, item := range strings.Split(text, ",") {
+		if strings.Contains(item, "-") {
+			return 0, errValue
+		}
+		_v, err := parse(strings.Replace(item, "..", "-", 1), b)
+		if err != nil {
+			return 0, err
+		}
+		v |= _v
+	}
+	return v, nil
+}
+
+// parseCalendarDate 解析日期，如 2027-03-05、*-*-01、03-05、*-02~03
+func (t *TimeSchedule) parseCalendarDate(spec string, tok field) error {
+	text, last := tok.text, ""
+	if i := strings.Index(text, "~"); i >= 0 {
+		text, last = text[:i]+"-*", text[i+1:]
+	}
+
+	parts := strings.Split(text, "-")
+	switch len(parts) {
+	case 2:
+		parts = append([]string{"*"}, parts...)
+	case 3:
+	default:
+		return newParseError(spec, tok, -1, "invalid date")
+	}
+
+	var err error
+	if t.year, err = parseCalendarField(parts[0], years); err != nil {
+		return newParseError(spec, tok, 6, err.Error())
+	}
+	if t.month, err = parseCalendarField(parts[1], months); err != nil {
+		return newParseError(spec, tok, 4, err.Error())
+	}
+	if last == "" {
+		if t.day, err = parseCalendarField(parts[2], days); err != nil {
+			return newParseError(spec, tok, 3, err.Error())
+		}
+		return nil
+	}
+
+	// ~n 表示倒数第 n 天，对应 lastDay 的 bit n-1
+	t.day = 0
+	for _, item := range strings.Split(last, ",") {
+		if err := t.parseCalendarLastDay(item); err != nil {
+			return newParseError(spec, tok, 3, err.Error())
+		}
+	}
+	return nil
+}
+
+// parseCalendarLastDay 解析 ~ 之后的倒数天数，如 03 或 07/1
+func (t *TimeSchedule) parseCalendarLastDay(item string) error {
+	slash := strings.Split(item, "/")
+	if len(slash) > 2 {
+		return errStep
+	}
+	n, err := strconv.ParseUint(slash[0], 10, 64)
+	if err != nil || n < 1 || n > 31 {
+		return errValue
+	}
+	step := n
+	if len(slash) == 2 {
+		if step, err = strconv.ParseUint(slash[1], 10, 64); err != nil || step == 0 {
+			return errStep
+		}
+	}
+
+	// 从倒数第 n 天开始每隔 step 天，直到最后一天
+	for i := int(n - 1); i >= 0; i -= int(step) {
+		t.lastDay = bitSet(t.lastDay, uint64(i), 1)
+	}
+	return nil
+}
+
+// parseCalendarTime 解析时间，如 09:00、*:0/15、08:05:40
+func (t *TimeSchedule) parseCalendarTime(spec string, tok field) error {
+	parts := strings.Split(tok.text, ":")
+	switch len(parts) {
+	case 2:
+		parts = append(parts, "00")
+	case 3:
+		if strings.Contains(parts[2], ".") {
+			return newParseError(spec, tok, 0, "fractional seconds are not supported")
+		}
+	default:
+		return newParseError(spec, tok, -1, "invalid time")
+	}
+
+	var err error
+	if t.hour, err = parseCalendarField(parts[0], hours); err != nil {
+		return newParseError(spec, tok, 2, err.Error())
+	}
+	if t.min, err = parseCalendarField(parts[1], minutes); err != nil {
+		return newParseError(spec, tok, 1, err.Error())
+	}
+	if t.second, err = parseCalendarField(parts[2], seconds); err != nil {
+		return newParseError(spec, tok, 0, err.Error())
+	}
+	return nil
+}
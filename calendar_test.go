@@ -0,0 +1,127 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+// 来自 systemd.time(7) 的规范化示例，左侧表达式与右侧规范形式应解析为相同的调度器
+// 年份超出 StartYear 到 StartYear+63 的示例见 Test_ParseCalendarInvalid
+func Test_ParseCalendarNormalized(t *testing.T) {
+	data := []struct {
+		expr       string
+		normalized string
+	}{
+		{"Sat,Thu,Mon..Wed,Sat..Sun", "Mon..Thu,Sat,Sun *-*-* 00:00:00"},
+		{"Wed *-1", "Wed *-*-01 00:00:00"},
+		{"Wed..Wed,Wed *-1", "Wed *-*-01 00:00:00"},
+		{"Wed, 17:48", "Wed *-*-* 17:48:00"},
+		{"*-*-7 0:0:0", "*-*-07 00:00:00"},
+		{"10-15", "*-10-15 00:00:00"},
+		{"monday *-12-* 17:00", "Mon *-12-* 17:00:00"},
+		{"Mon,Fri *-*-3,1,2 *:30:45", "Mon,Fri *-*-01,02,03 *:30:45"},
+		{"12,14,13,12:20,10,30", "*-*-* 12,13,14:10,20,30:00"},
+		{"12..14:10,20,30", "*-*-* 12..14:10,20,30:00"},
+		{"mon,fri *-1/2-1,3 *:30:45", "Mon,Fri *-01/2-01,03 *:30:45"},
+		{"03-05 08:05:40", "*-03-05 08:05:40"},
+		{"08:05:40", "*-*-* 08:05:40"},
+		{"05:40", "*-*-* 05:40:00"},
+		{"Sat,Sun 12-05 08:05:40", "Sat,Sun *-12-05 08:05:40"},
+		{"Sat,Sun 08:05:40", "Sat,Sun *-*-* 08:05:40"},
+		{"03-05", "*-03-05 00:00:00"},
+		{"hourly", "*-*-* *:00:00"},
+		{"daily", "*-*-* 00:00:00"},
+		{"daily UTC", "*-*-* 00:00:00 UTC"},
+		{"monthly", "*-*-01 00:00:00"},
+		{"weekly", "Mon *-*-* 00:00:00"},
+		{"weekly Pacific/Auckland", "Mon *-*-* 00:00:00 Pacific/Auckland"},
+		{"yearly", "*-01-01 00:00:00"},
+		{"annually", "*-01-01 00:00:00"},
+		{"*:2/3", "*-*-* *:02/3:00"},
+	}
+
+	for _, p := range data {
+		t.Run(p.expr, func(t *testing.T) {
+			s, err := ParseCalendar(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := ParseCalendar(p.normalized)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get, want := s.(*TimeSchedule).String(), n.(*TimeSchedule).String(); get != want {
+				t.Errorf("want: %s, get: %s", want, get)
+			}
+		})
+	}
+}
+
+func Test_ParseCalendarNext(t *testing.T) {
+	now := time.Date(2019, 5, 18, 10, 0, 0, 0, time.UTC) // 周六
+	data := []struct {
+		name string
+		expr string
+		next time.Time
+	}{
+		{"工作日", "Mon..Fri *-*-* 09:00:00 UTC", time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC)},
+		{"每月 1 日", "*-*-01 00:00 UTC", time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"每周", "weekly UTC", time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)},
+		{"每分钟", "minutely UTC", time.Date(2019, 5, 18, 10, 1, 0, 0, time.UTC)},
+		{"每季度", "quarterly UTC", time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"每半年", "semiannually UTC", time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"每 15 分钟", "*:0/15 UTC", time.Date(2019, 5, 18, 10, 15, 0, 0, time.UTC)},
+		{"倒数第 3 天", "*-02~03 UTC", time.Date(2020, 2, 27, 0, 0, 0, 0, time.UTC)},
+		{"最后一个周一", "Mon *-05~07/1 12:00 UTC", time.Date(2019, 5, 27, 12, 0, 0, 0, time.UTC)},
+		{"指定年份", "2027-03-05 05:40 UTC", time.Date(2027, 3, 5, 5, 40, 0, 0, time.UTC)},
+		{"年份区间", "2027..2029-02..04-05 UTC", time.Date(2027, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{"时区", "*-*-* 09:00 Asia/Shanghai", time.Date(2019, 5, 19, 1, 0, 0, 0, time.UTC)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := ParseCalendar(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := s.Next(now); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+}
+
+func Test_ParseCalendarInvalid(t *testing.T) {
+	data := []struct {
+		name   string
+		expr   string
+		token  string
+		reason string
+	}{
+		// systemd.time(7) 的示例，年份超出范围
+		{"两位年份", "Mon,Sun 12-*-* 2,1:23", "12-*-*", "invalid value"},
+		{"年份过小", "2003-03-05 05:40 UTC", "2003-03-05", "invalid value"},
+		{"小数秒", "05:40:23.4200004/3.1700005", "05:40:23.4200004/3.1700005", "fractional seconds are not supported"},
+		{"空表达式", " ", "", "empty calendar expression"},
+		{"星期", "Mon..Fro", "Mon..Fro", "invalid value"},
+		{"时区", "daily Mars/Olympus", "Mars/Olympus", "unknown time zone"},
+		{"日期", "1-2-3-4", "1-2-3-4", "invalid date"},
+		{"时间", "1:2:3:4", "1:2:3:4", "invalid time"},
+		{"小时", "25:00", "25:00", "value 25 out of range 0-23"},
+		{"重复", "05:40 06:00", "06:00", "unexpected token"},
+		{"倒数天数", "*-*~32", "*-*~32", "invalid value"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := ParseCalendar(p.expr)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("err: %v", err)
+			}
+			if pe.Token != p.token || pe.Reason != p.reason {
+				t.Errorf("token: %q, reason: %q", pe.Token, pe.Reason)
+			}
+		})
+	}
+}
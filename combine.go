@@ -0,0 +1,328 @@
+package corn
+
+import (
+	"strings"
+	"time"
+)
+
+// occurs t 是否为 s 的执行时间
+func occurs(s Scheduler, t time.Time) bool {
+	return s.Next(t.Add(-time.Nanosecond)).Equal(t)
+}
+
+// combineLimit 组合调度器从 t 起最多查找到的时间，避免子调度器的执行时间永远没有交集时一直查找
+func combineLimit(t time.Time, forward bool) time.Time {
+	if forward {
+		return t.AddDate(searchYears, 0, 0)
+	}
+	return t.AddDate(-searchYears, 0, 0)
+}
+
+// maxCombineSteps 组合调度器一次查找最多推进候选时间的次数，避免子调度器的执行时间很密但没有交集时长时间查找
+// 查找在 Run 的 goroutine 中进行，耗时过长会阻塞所有任务
+const maxCombineSteps = 100000
+
+// UnionSchedule 合并多个调度器的执行时间，任一调度器的执行时间都会执行(相同的时间只执行一次)
+type UnionSchedule struct {
+	schedulers []Scheduler
+}
+
+// Union 合并 a 和 others 的执行时间，如工作日 9:00 和周末 10:00 执行:
+//  weekday, _ := corn.Parse("0 0 9 * * 1-5")
+//  weekend, _ := corn.Parse("0 0 10 * * 0,6")
+//  s := corn.Union(weekday, weekend)
+func Union(a Scheduler, others ...Scheduler) *UnionSchedule {
+	return &UnionSchedule{schedulers: append([]Scheduler{a}, others...)}
+}
+
+// Next 各调度器晚于 t 的下一次执行时间中最早的一个，都没有时返回零时
+func (u *UnionSchedule) Next(t time.Time) time.Time {
+	var res time.Time
+	for _, s := range u.schedulers {
+		if n := s.Next(t); n.After(t) && (res.IsZero() || n.Before(res)) {
+			res = n
+		}
+	}
+	return res
+}
+
+// Prev 各调度器早于 t 的上一次执行时间中最晚的一个，都没有时返回零时
+func (u *UnionSchedule) Prev(t time.Time) time.Time {
+	var res time.Time
+	for _, s := range u.schedulers {
+		if p := Prev(s, t); !p.IsZero() && p.After(res) {
+			res = p
+		}
+	}
+	return res
+}
+
+// Last 各调度器最后一次执行时间中最晚的一个，任一调度器没有限制时返回零时
+func (u *UnionSchedule) Last() time.Time {
+	var res time.Time
+	for _, s := range u.schedulers {
+		last := s.Last()
+		if last.IsZero() {
+			return time.Time{}
+		}
+		if last.After(res) {
+			res = last
+		}
+	}
+	return res
+}
+
+// Describe 实现 Describer
+func (u *UnionSchedule) Describe(lang Lang) string {
+	if lang == LangEn {
+		return describeAll(u.schedulers, lang, "; or ")
+	}
+	return describeAll(u.schedulers, lang, "；或者")
+}
+
+var _ Scheduler = new(UnionSchedule)
+
+// IntersectSchedule 多个调度器共同的执行时间，所有调度器都在该时间执行时才执行
+type IntersectSchedule struct {
+	schedulers []Scheduler
+}
+
+// Intersect a 和 others 共同的执行时间，如工作时间内每 15 分钟执行:
+//  every, _ := corn.Parse("0 */15 * * * *")
+//  hours, _ := corn.Parse("* * 9-17 * * 1-5")
+//  s := corn.Intersect(every, hours)
+// 子调度器在 50 年内或推进 10 万次候选时间后仍没有共同的执行时间时 Next 返回零时
+func Intersect(a Scheduler, others ...Scheduler) *IntersectSchedule {
+	return &IntersectSchedule{schedulers: append([]Scheduler{a}, others...)}
+}
+
+// Next 晚于 t 的下一个共同执行时间，没有时返回零时
+// 依次将候选时间推进到各调度器不早于它的执行时间，直到所有调度器的结果相同
+func (i *IntersectSchedule) Next(t time.Time) time.Time {
+	limit := combineLimit(t, true)
+	cand := i.schedulers[0].Next(t)
+	for n := 0; n < maxCombineSteps && !cand.IsZero() && cand.After(t) && !cand.After(limit); n++ {
+		matched := true
+		for _, s := range i.schedulers {
+			n := s.Next(cand.Add(-time.Nanosecond))
+			if n.IsZero() || n.Before(cand) {
+				return time.Time{}
+			}
+			if !n.Equal(cand) {
+				cand, matched = n, false
+			}
+		}
+		if matched {
+			return cand
+		}
+	}
+	return time.Time{}
+}
+
+// Prev 早于 t 的上一个共同执行时间，没有时返回零时
+func (i *IntersectSchedule) Prev(t time.Time) time.Time {
+	limit := combineLimit(t, false)
+	cand := Prev(i.schedulers[0], t)
+	for n := 0; n < maxCombineSteps && !cand.IsZero() && cand.Before(t) && !cand.Before(limit); n++ {
+		matched := true
+		for _, s := range i.schedulers {
+			p := Prev(s, cand.Add(time.Nanosecond))
+			if p.IsZero() || p.After(cand) {
+				return time.Time{}
+			}
+			if !p.Equal(cand) {
+				cand, matched = p, false
+			}
+		}
+		if matched {
+			return cand
+		}
+	}
+	return time.Time{}
+}
+
+// Last 最后一个共同执行时间，所有调度器都没有限制时返回零时
+func (i *IntersectSchedule) Last() time.Time {
+	var end time.Time
+	for _, s := range i.schedulers {
+		if last := s.Last(); !last.IsZero() && (end.IsZero() || last.Before(end)) {
+			end = last
+		}
+	}
+	if end.IsZero() {
+		return time.Time{}
+	}
+	return i.Prev(end.Add(time.Nanosecond))
+}
+
+// Describe 实现 Describer
+func (i *IntersectSchedule) Describe(lang Lang) string {
+	if lang == LangEn {
+		return "When all of: " + describeAll(i.schedulers, lang, "; ")
+	}
+	return "同时满足: " + describeAll(i.schedulers, lang, "；")
+}
+
+var _ Scheduler = new(IntersectSchedule)
+
+// ExceptSchedule 从调度器的执行时间中去掉另一个调度器的执行时间
+type ExceptSchedule struct {
+	base, blackout Scheduler
+}
+
+// Except base 中不是 blackout 执行时间的执行时间，如工作时间内每 15 分钟执行，但每月 1 日不执行:
+//  base, _ := corn.Parse("0 */15 9-17 * * 1-5")
+//  first, _ := corn.Parse("* * * 1 * *")
+//  s := corn.Except(base, first)
+// blackout 为 TimeSchedule 时排除其执行的每一秒(包括其中不是整秒的时间)，因此排除整天时应使用每秒执行的表达式
+// (如上例中的 * * * 1 * *，0 0 0 1 * * 只排除 1 日 00:00:00)，按日期排除节假日时可以使用 BusinessDays
+// 50 年内或连续 10 万个执行时间(排除的时间段)都被排除时 Next 返回零时
+func Except(base, blackout Scheduler) *ExceptSchedule {
+	return &ExceptSchedule{base: base, blackout: blackout}
+}
+
+
+// excluded cand 是否被排除，被排除时返回需要跳过的时间段的边界:
+// forward 时为结束时间(不包含)，否则为开始时间，查找范围不超过 limit
+func (e *ExceptSchedule) excluded(cand, limit time.Time, forward bool) (time.Time, bool) {
+	ts, ok := e.blackout.(*TimeSchedule)
+	if !ok {
+		if !occurs(e.blackout, cand) {
+			return time.Time{}, false
+		}
+		if forward {
+			return cand.Add(time.Nanosecond), true
+		}
+		return cand, true
+	}
+
+	sec := cand.Truncate(time.Second)
+	if !occurs(ts, sec) {
+		return time.Time{}, false
+	}
+	if forward {
+		return ts.runEnd(sec, limit), true
+	}
+	return ts.runStart(sec, limit), true
+}
+
+// Next 晚于 t 且没有被排除的下一次执行时间，没有时返回零时
+func (e *ExceptSchedule) Next(t time.Time) time.Time {
+	limit := combineLimit(t, true)
+	cand := e.base.Next(t)
+	for i := 0; i < maxCombineSteps && !cand.IsZero() && cand.After(t) && !cand.After(limit); i++ {
+		end, ok := e.excluded(cand, limit, true)
+		if !ok {
+			return cand
+		}
+		t = end.Add(-time.Nanosecond)
+		cand = e.base.Next(t)
+	}
+	return time.Time{}
+}
+
+// Prev 早于 t 且没有被排除的上一次执行时间，没有时返回零时
+func (e *ExceptSchedule) Prev(t time.Time) time.Time {
+	limit := combineLimit(t, false)
+	cand := Prev(e.base, t)
+	for i := 0; i < maxCombineSteps && !cand.IsZero() && cand.Before(t) && !cand.Before(limit); i++ {
+		start, ok := e.excluded(cand, limit, false)
+		if !ok {
+			return cand
+		}
+		t = start
+		cand = Prev(e.base, t)
+	}
+	return time.Time{}
+}
+
+// span 执行时间 r(整秒)所在的每秒都执行的最大时间单位(秒、分、时或天) [start, end)
+// 取决于秒、分、时字段是否没有限制，单位内有夏令时切换时使用更小的单位
+func (t *TimeSchedule) span(r time.Time) (start, end time.Time) {
+	hour, min, sec := t.timeFields()
+	l := r.In(t.loc)
+	_, offset := l.Zone()
+	same := func(s, e time.Time) bool {
+		_, o1 := s.In(t.loc).Zone()
+		_, o2 := e.Add(-time.Nanosecond).In(t.loc).Zone()
+		return o1 == offset && o2 == offset
+	}
+
+	start, end = r, r.Add(time.Second)
+	if !sec.full() {
+		return
+	}
+	if s := r.Add(-time.Duration(l.Second()) * time.Second); same(s, s.Add(time.Minute)) {
+		start, end = s, s.Add(time.Minute)
+	}
+	if !min.full() {
+		return
+	}
+	if s := r.Add(-time.Duration(l.Minute()*60+l.Second()) * time.Second); same(s, s.Add(time.Hour)) {
+		start, end = s, s.Add(time.Hour)
+	}
+	if !hour.full() {
+		return
+	}
+	y, m, d := l.Date()
+	if s, e := time.Date(y, m, d, 0, 0, 0, 0, t.loc), time.Date(y, m, d+1, 0, 0, 0, 0, t.loc); same(s, e) {
+		start, end = s, e
+	}
+	return
+}
+
+// runEnd 执行时间 r(整秒)起每秒都执行的连续时间段的结束时间(不包含)，最晚为 limit
+func (t *TimeSchedule) runEnd(r, limit time.Time) time.Time {
+	for {
+		_, end := t.span(r)
+		if !end.Before(limit) {
+			return limit
+		}
+		if !occurs(t, end) {
+			return end
+		}
+		r = end
+	}
+}
+
+// runStart 执行时间 r(整秒)之前每秒都执行的连续时间段的开始时间，最早为 limit
+func (t *TimeSchedule) runStart(r, limit time.Time) time.Time {
+	for {
+		start, _ := t.span(r)
+		if !start.After(limit) {
+			return limit
+		}
+		if !occurs(t, start.Add(-time.Second)) {
+			return start
+		}
+		r = start.Add(-time.Second)
+	}
+}
+
+// Last 最后一次没有被排除的执行时间，base 没有限制时返回零时
+func (e *ExceptSchedule) Last() time.Time {
+	last := e.base.Last()
+	if last.IsZero() {
+		return time.Time{}
+	}
+	return e.Prev(last.Add(time.Nanosecond))
+}
+
+// Describe 实现 Describer
+func (e *ExceptSchedule) Describe(lang Lang) string {
+	if lang == LangEn {
+		return Describe(e.base, lang) + ", except " + Describe(e.blackout, lang)
+	}
+	return Describe(e.base, lang) + "，但不包括" + Describe(e.blackout, lang)
+}
+
+var _ Scheduler = new(ExceptSchedule)
+
+// describeAll 用 sep 连接各调度器的描述
+func describeAll(schedulers []Scheduler, lang Lang, sep string) string {
+	items := make([]string, 0, len(schedulers))
+	for _, s := range schedulers {
+		items = append(items, Describe(s, lang))
+	}
+	return strings.Join(items, sep)
+}
@@ -0,0 +1,194 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+// mustParse 解析 UTC 时区的表达式
+func mustParse(t *testing.T, expr string) Scheduler {
+	t.Helper()
+	s, err := Parse("CRON_TZ=UTC " + expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_Combine(t *testing.T) {
+	from := time.Date(2019, 4, 30, 16, 50, 0, 0, time.UTC)
+	d := func(day, h, m int) time.Time {
+		return time.Date(2019, 5, day, h, m, 0, 0, time.UTC)
+	}
+	at := func(day, h, m int) time.Time {
+		return time.Date(2019, 4, day, h, m, 0, 0, time.UTC)
+	}
+
+	business := Intersect(mustParse(t, "0 */15 * * * *"), mustParse(t, "* * 9-17 * * 1-5"))
+	data := []struct {
+		name string
+		s    Scheduler
+		want []time.Time
+	}{
+		{"合并", Union(mustParse(t, "0 0 9 * * 1-5"), mustParse(t, "0 0 10 * * 0,6"), &FixSchedule{d(1, 12, 0)}),
+			[]time.Time{d(1, 9, 0), d(1, 12, 0), d(2, 9, 0), d(3, 9, 0), d(4, 10, 0)}},
+		{"相同的时间只执行一次", Union(mustParse(t, "0 0 * * * *"), mustParse(t, "0 0,30 * * * *")),
+			[]time.Time{at(30, 17, 0), at(30, 17, 30), at(30, 18, 0)}},
+		{"交集", business,
+			[]time.Time{at(30, 17, 0), at(30, 17, 15), at(30, 17, 30), at(30, 17, 45), d(1, 9, 0)}},
+		{"排除", Except(business, mustParse(t, "* * * 1 * *")),
+			[]time.Time{at(30, 17, 0), at(30, 17, 15), at(30, 17, 30), at(30, 17, 45), d(2, 9, 0)}},
+		{"多个交集", Intersect(mustParse(t, "0 0 0 * * *"), mustParse(t, "0 0 0 13 * *"), mustParse(t, "0 0 0 * * 5")),
+			[]time.Time{time.Date(2019, 9, 13, 0, 0, 0, 0, time.UTC), time.Date(2019, 12, 13, 0, 0, 0, 0, time.UTC)}},
+		{"排除固定时间", Except(mustParse(t, "0 0 12 * * *"), &FixSchedule{d(1, 12, 0)}),
+			[]time.Time{at(30, 12, 0).AddDate(0, 0, 2), d(3, 12, 0)}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			get := NextN(p.s, from, len(p.want))
+			if len(get) != len(p.want) {
+				t.Fatalf("want: %v, get: %v", p.want, get)
+			}
+			for i := range get {
+				if !get[i].Equal(p.want[i]) {
+					t.Errorf("第 %d 次 want: %s, get: %s", i, p.want[i], get[i])
+				}
+			}
+			for i := len(get) - 1; i > 0; i-- {
+				if prev := Prev(p.s, get[i]); !prev.Equal(get[i-1]) {
+					t.Errorf("Prev(%s) want: %s, get: %s", get[i], get[i-1], prev)
+				}
+			}
+		})
+	}
+}
+
+func Test_CombineLast(t *testing.T) {
+	ds := &DurationSchedule{start: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), frequency: time.Hour, count: 24}
+	data := []struct {
+		name string
+		s    Scheduler
+		want time.Time
+	}{
+		{"合并没有限制", Union(ds, mustParse(t, "0 0 9 * * *")), time.Time{}},
+		{"合并", Union(ds, &FixSchedule{time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}), time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"交集", Intersect(mustParse(t, "0 0 */5 * * *"), ds), time.Date(2019, 5, 1, 20, 0, 0, 0, time.UTC)},
+		{"交集没有限制", Intersect(mustParse(t, "0 0 */5 * * *"), mustParse(t, "0 0 9 * * *")), time.Time{}},
+		{"排除", Except(ds, mustParse(t, "* * 20-23 * * *")), time.Date(2019, 5, 1, 19, 0, 0, 0, time.UTC)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			if get := p.s.Last(); !get.Equal(p.want) {
+				t.Errorf("want: %s, get: %s", p.want, get)
+			}
+		})
+	}
+}
+
+func Test_CombineNoOccurrence(t *testing.T) {
+	from := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	if get := Intersect(mustParse(t, "0 0 * * * *"), mustParse(t, "0 30 * * * *")).Next(from); !get.IsZero() {
+		t.Errorf("没有交集 get: %s", get)
+	}
+	if get := Except(mustParse(t, "0 0 9 1 * *"), mustParse(t, "* * * 1 * *")).Next(from); !get.IsZero() {
+		t.Errorf("全部排除 get: %s", get)
+	}
+
+	// 执行时间很密但没有交集时，推进一定次数后结束查找
+	even := &DurationSchedule{start: from, frequency: 2 * time.Second}
+	odd := &DurationSchedule{start: from.Add(time.Second), frequency: 2 * time.Second}
+	for _, s := range []*IntersectSchedule{
+		Intersect(even, odd),
+		Intersect(mustParse(t, "0 * * * * *"), mustParse(t, "30 * * * * *")),
+	} {
+		start := time.Now()
+		if get := s.Next(from); !get.IsZero() {
+			t.Errorf("没有交集 get: %s", get)
+		}
+		if get := s.Prev(from); !get.IsZero() {
+			t.Errorf("没有交集 Prev get: %s", get)
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Errorf("耗时: %s", d)
+		}
+	}
+}
+
+// 排除连续的时间段时直接跳到时间段的边界，不逐个检查 base 的执行时间
+func Test_ExceptRun(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		name     string
+		base     string
+		blackout string
+		want     time.Time
+	}{
+		{"排除整月", "* * * * * *", "* * * * 1 *", time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"排除工作时间", "* * * * * *", "* * 0-8,10-23 * * *", time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"排除多个分钟", "*/10 * * * * *", "* 0-29 * * * *", time.Date(2019, 1, 1, 0, 30, 0, 0, time.UTC)},
+		{"排除多个秒", "* * * * * *", "0-44 * * * * *", time.Date(2019, 1, 1, 0, 0, 45, 0, time.UTC)},
+		{"全部排除", "* * * * * *", "* * * * * *", time.Time{}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s := Except(mustParse(t, p.base), mustParse(t, p.blackout))
+			start := time.Now()
+			if get := s.Next(from); !get.Equal(p.want) {
+				t.Errorf("want: %s, get: %s", p.want, get)
+			}
+			if !p.want.IsZero() {
+				if get := s.Prev(p.want.Add(time.Nanosecond)); !get.Equal(p.want) {
+					t.Errorf("Prev want: %s, get: %s", p.want, get)
+				}
+				if get := s.Prev(p.want); !get.Before(from) {
+					t.Errorf("Prev(%s) get: %s", p.want, get)
+				}
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("耗时: %s", d)
+			}
+		})
+	}
+
+	// 纽约 2019-11-03 01:00 至 01:59 重复，blackout 只在第一次(EDT)执行，因此只排除第一次
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	base, err := Parse("CRON_TZ=America/New_York CRON_DST=twice 0 */30 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blackout, err := Parse("CRON_TZ=America/New_York * * 0-1 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Except(base, blackout)
+	get := NextN(s, time.Date(2019, 11, 2, 23, 0, 0, 0, ny), 3)
+	want := []string{"2019-11-02 23:30 EDT", "2019-11-03 01:00 EST", "2019-11-03 01:30 EST"}
+	for i := range want {
+		if i >= len(get) || get[i].In(ny).Format("2006-01-02 15:04 MST") != want[i] {
+			t.Fatalf("want: %v, get: %v", want, get)
+		}
+	}
+	if prev := s.Prev(get[1]); !prev.Equal(get[0]) {
+		t.Errorf("Prev want: %s, get: %s", get[0], prev)
+	}
+}
+
+func Test_CombineDescribe(t *testing.T) {
+	s := Except(Union(mustParse(t, "0 0 9 * * *"), mustParse(t, "0 0 18 * * *")), mustParse(t, "* * * 1 * *"))
+	if get := Describe(s, LangZh); get != "每天 09:00 (UTC)；或者每天 18:00 (UTC)，但不包括每月 1 日 每秒 (UTC)" {
+		t.Errorf("get: %s", get)
+	}
+	if get := Describe(s, LangEn); get != "At 09:00 (UTC); or At 18:00 (UTC), except Every second, on day 1 of the month (UTC)" {
+		t.Errorf("get: %s", get)
+	}
+	i := Intersect(mustParse(t, "0 */15 * * * *"), mustParse(t, "* * 9-17 * * 1-5"))
+	if get := Describe(i, LangZh); get != "同时满足: 每 15 分钟 (UTC)；周一至周五 9 点至 17 点的每秒 (UTC)" {
+		t.Errorf("get: %s", get)
+	}
+}
@@ -1,6 +1,7 @@
 package corn
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -36,18 +37,52 @@ type Corner interface {
 	Stop()
 }
 
+// Lister 可以列出任务的 Corner，ExportICS 需要 Corner 实现该接口
+type Lister interface {
+	// Entries 当前所有任务，按添加顺序排列
+	// 任何情况下都可以调用(包括运行过程中)，并发安全
+	Entries() []Entry
+}
+
+// Entry 任务及其调度器
+type Entry struct {
+	// ID 任务唯一标识，与 Add 的返回值相同
+	ID string
+	Scheduler
+	Job
+}
+
 // Cron
 // todo：封装接口对外提供功能
 type Cron struct {
 	Corner
+
+	// AddWithCorn 使用的表达式解析器
+	parser *Parser
 }
 
 // CronOption 扩展 Cron 功能使用
 type CronOption func(c *Cron)
 
+// WithParser 指定 AddWithCorn 使用的表达式解析器，默认与 Parse 相同
+func WithParser(p *Parser) CronOption {
+	return func(c *Cron) {
+		c.parser = p
+	}
+}
+
+// Entries 实现 Lister，Corner 没有实现 Lister 时返回 nil
+func (c *Cron) Entries() []Entry {
+	if l, ok := c.Corner.(Lister); ok {
+		return l.Entries()
+	}
+	return nil
+}
+
 func NewCorn(opts ...CronOption) *Cron {
 	c := &Cron{}
 	c.Corner = defaultCorner()
+	c.parser = defaultParser
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -63,7 +98,18 @@ func (c *Cron) AddWithTime(t time.Time, f Func) error {
 
 // AddWithCorn 添加重复执行定时任务
 func (c *Cron) AddWithCorn(expr string, f Func) error {
-	scheduler, err := Parse(expr)
+	scheduler, err := c.parser.Parse(expr)
+	if err != nil {
+		return err
+	}
+	c.Add(scheduler, JobFunc(f))
+	return nil
+}
+
+// AddWithHashCorn 添加重复执行定时任务，表达式中的 H 由 key(如任务名称)确定取值
+// 用于分散大量相同表达式的任务，如 0 H * * * * 表示每小时的某一分钟执行
+func (c *Cron) AddWithHashCorn(key, expr string, f Func) error {
+	scheduler, err := c.parser.ParseWithKey(expr, key)
 	if err != nil {
 		return err
 	}
@@ -93,7 +139,10 @@ type cron struct {
 
 	state int64
 
-	wg   *sync.WaitGroup
+	wg *sync.WaitGroup
+
+	// mu 保护 jobs，Run 与 Add、Entries 及执行结束的 Job 会并发访问
+	mu   sync.Mutex
 	jobs map[string]*entity
 	node *snowflake.Node
 }
@@ -115,7 +164,9 @@ func (c *cron) Add(scheduler Scheduler, job Job) string {
 	select {
 	case c.add <- e:
 	default:
+		c.mu.Lock()
 		c.jobs[id] = e
+		c.mu.Unlock()
 	}
 
 	return id
@@ -126,14 +177,35 @@ func (c *cron) Delete(id string) {
 	return
 }
 
+// Entries 按 id(雪花算法生成，随时间递增)排序的任务列表
+func (c *cron) Entries() []Entry {
+	c.mu.Lock()
+	entries := make([]Entry, 0, len(c.jobs))
+	for _, e := range c.jobs {
+		entries = append(entries, Entry{ID: e.id, Scheduler: e.Scheduler, Job: e.Job})
+	}
+	c.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].ID, entries[j].ID
+		return len(a) < len(b) || len(a) == len(b) && a < b
+	})
+	return entries
+}
+
 // Run
-// todo: 目前并发调用 Run 和 Add 方法会造成 c.jobs 的并发读写，后续优化
 func (c *cron) Run() {
 	if !atomic.CompareAndSwapInt64(&c.state, def, running) {
 		return
 	}
+	c.mu.Lock()
+	jobs := make([]*entity, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	c.mu.Unlock()
 	go func() {
-		for _, job := range c.jobs {
+		for _, job := range jobs {
 			c.add <- job
 		}
 	}()
@@ -141,11 +213,15 @@ func (c *cron) Run() {
 	for {
 		select {
 		case id := <-c.del:
+			c.mu.Lock()
 			delete(c.jobs, id)
+			c.mu.Unlock()
 		case e := <-c.add:
 			c.addJob(e)
 		case id := <-c.work:
+			c.mu.Lock()
 			e, ok := c.jobs[id]
+			c.mu.Unlock()
 			if !ok {
 				continue
 			}
@@ -171,9 +247,11 @@ func (c *cron) addJob(e *entity) {
 	if next.IsZero() || next.Before(now) {
 		return
 	}
+	c.mu.Lock()
 	c.jobs[e.id] = e
+	c.mu.Unlock()
 	go func(id string) {
-		time.Sleep(next.Sub(next))
+		time.Sleep(next.Sub(now))
 		c.work <- e.id
 	}(e.id)
 }
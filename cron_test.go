@@ -1,6 +1,7 @@
 package corn
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,7 +14,6 @@ func Test_cron_Add(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want string
 	}{
 		{
 			name: "示例: 未运行过程中添加 job",
@@ -28,9 +28,108 @@ func Test_cron_Add(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := defaultCorner()
-			if got := c.Add(tt.args.scheduler, tt.args.job); got != tt.want {
-				t.Errorf("Add() = %v, want %v", got, tt.want)
+			if got := c.Add(tt.args.scheduler, tt.args.job); got == "" {
+				t.Errorf("Add() 返回空的 id")
 			}
 		})
 	}
 }
+
+func Test_Cron_AddWithCorn(t *testing.T) {
+	c := NewCorn()
+	if err := c.AddWithCorn("0 30 9 * * *", func() error { return nil }); err != nil {
+		t.Error(err)
+	}
+	if err := c.AddWithCorn("30 9 * * *", func() error { return nil }); err == nil {
+		t.Error("默认解析器期望返回错误")
+	}
+
+	if err := c.AddWithHashCorn("job", "0 H * * * *", func() error { return nil }); err != nil {
+		t.Error(err)
+	}
+	if err := c.AddWithCorn("0 H * * * *", func() error { return nil }); err == nil {
+		t.Error("未指定 key 时期望返回错误")
+	}
+
+	c = NewCorn(WithParser(NewParser()))
+	if err := c.AddWithCorn("30 9 * * *", func() error { return nil }); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_cron_Entries(t *testing.T) {
+	c := defaultCorner()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, c.Add(&FixSchedule{time.Now().Add(time.Hour)}, JobFunc(func() error { return nil })))
+	}
+
+	entries := c.(Lister).Entries()
+	if len(entries) != len(ids) {
+		t.Fatalf("want: %d, get: %d", len(ids), len(entries))
+	}
+	for i, e := range entries {
+		if e.ID != ids[i] || e.Scheduler == nil || e.Job == nil {
+			t.Errorf("want: %s, get: %+v", ids[i], e)
+		}
+	}
+}
+
+// 运行过程中并发添加和列出任务，需要使用 go test -race 检查
+func Test_cron_EntriesRunning(t *testing.T) {
+	c := defaultCorner()
+	l := c.(Lister)
+	job := JobFunc(func() error { return nil })
+	c.Add(&FixSchedule{time.Now().Add(time.Hour)}, job)
+	go c.Run()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				c.Add(&FixSchedule{time.Now().Add(time.Hour)}, job)
+				l.Entries()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Run 中添加的任务在处理后才会出现
+	var entries []Entry
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if entries = l.Entries(); len(entries) == 101 {
+			break
+		}
+	}
+	if len(entries) != 101 {
+		t.Fatalf("want: 101, get: %d", len(entries))
+	}
+	for i := 1; i < len(entries); i++ {
+		if a, b := entries[i-1].ID, entries[i].ID; len(a) > len(b) || len(a) == len(b) && a >= b {
+			t.Errorf("第 %d 个任务顺序错误: %s, %s", i, a, b)
+		}
+	}
+}
+
+// 任务在执行时间到达后才执行
+func Test_cron_RunDelay(t *testing.T) {
+	c := defaultCorner()
+	at := time.Now().Add(200 * time.Millisecond)
+	ran := make(chan time.Time, 1)
+	c.Add(&FixSchedule{at}, JobFunc(func() error {
+		ran <- time.Now()
+		return nil
+	}))
+	go c.Run()
+
+	select {
+	case get := <-ran:
+		if get.Before(at) {
+			t.Errorf("want: %s 之后执行, get: %s", at, get)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("任务没有执行")
+	}
+}
@@ -0,0 +1,662 @@
+package corn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Lang 描述使用的语言
+type Lang string
+
+// 支持的语言
+const (
+	LangZh Lang = "zh"
+	LangEn Lang = "en"
+)
+
+// Describer 可以用自然语言描述自身的调度器
+type Describer interface {
+	Describe(lang Lang) string
+}
+
+// Describe 用自然语言描述调度器的执行时间，如:
+//  0 2 8-20/3 * * *
+//  zh: 8 点至 20 点每 3 小时的第 2 分钟
+//  en: At 2 minutes past the hour, every 3 hours between 08:00 and 20:00
+// 不支持的语言按中文描述
+func Describe(s Scheduler, lang Lang) string {
+	if lang != LangEn {
+		lang = LangZh
+	}
+
+	switch v := s.(type) {
+	case Describer:
+		return v.Describe(lang)
+	case *TimeSchedule:
+		if lang == LangEn {
+			return describeTimeEn(v)
+		}
+		return describeTimeZh(v)
+	case *DurationSchedule:
+		if lang == LangEn {
+			if v.count > 0 {
+				return fmt.Sprintf("Every %s, %s, starting at %s", formatDurationEn(v.frequency), pluralEn(uint64(v.count), "time"), v.start.Format(describeLayout))
+			}
+			return fmt.Sprintf("Every %s, starting at %s", formatDurationEn(v.frequency), v.start.Format(describeLayout))
+		}
+		if v.count > 0 {
+			return fmt.Sprintf("从 %s 开始每隔 %s，共 %d 次", v.start.Format(describeLayout), formatDurationZh(v.frequency), v.count)
+		}
+		return fmt.Sprintf("从 %s 开始每隔 %s", v.start.Format(describeLayout), formatDurationZh(v.frequency))
+	case *FixSchedule:
+		if lang == LangEn {
+			return fmt.Sprintf("Once at %s", v.rTime.Format(describeLayout))
+		}
+		return fmt.Sprintf("在 %s 执行一次", v.rTime.Format(describeLayout))
+	}
+
+	if lang == LangEn {
+		return fmt.Sprintf("Custom schedule %T", s)
+	}
+	return fmt.Sprintf("自定义调度器 %T", s)
+}
+
+// describeLayout 描述中使用的时间格式
+const describeLayout = "2006-01-02 15:04:05 MST"
+
+// fieldMask 字段的 bit 位及取值范围
+type fieldMask struct {
+	mask     uint64
+	min, max uint64
+}
+
+// full 字段没有限制
+func (f fieldMask) full() bool {
+	for i := f.min; i <= f.max; i++ {
+		if f.mask&(1<<i) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// spans 字段的取值区间
+func (f fieldMask) spans() []span {
+	return spans(f.mask, f.min, f.max)
+}
+
+// single 字段是否只有一个取值
+func (f fieldMask) single() (uint64, bool) {
+	ss := f.spans()
+	if len(ss) == 1 && ss[0].start == ss[0].end {
+		return ss[0].start, true
+	}
+	return 0, false
+}
+
+// every 字段是否为从最小值开始的等间隔取值，如 */15
+func (f fieldMask) every() (uint64, bool) {
+	ss := f.spans()
+	if len(ss) == 1 && ss[0].step > 1 && ss[0].start == f.min && ss[0].end+ss[0].step > f.max {
+		return ss[0].step, true
+	}
+	return 0, false
+}
+
+// timeFields 时、分、秒字段
+func (t *TimeSchedule) timeFields() (hour, min, sec fieldMask) {
+	return fieldMask{t.hour, 0, 23}, fieldMask{t.min, 0, 59}, fieldMask{t.second, 0, 59}
+}
+
+// hasDayRule 是否有日期的特殊规则
+func (t *TimeSchedule) hasDayRule() bool {
+	return t.lastDay != 0 || t.nearestWeekday != 0 || t.lastWeekday
+}
+
+// hasWeekDayRule 是否有星期的特殊规则
+func (t *TimeSchedule) hasWeekDayRule() bool {
+	return t.nthWeekDay != [7]uint8{} || t.lastWeekDay != 0
+}
+
+// joinEn 按英文习惯连接列表: a, b and c
+func joinEn(items []string, conj string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + conj + " " + items[len(items)-1]
+}
+
+var (
+	monthNamesEn = [...]string{"", "January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+	ordinalsEn  = [...]string{"", "first", "second", "third", "fourth", "fifth"}
+	weekNamesZh = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+)
+
+// describeSpansEn 用英文描述字段取值，unit 为间隔的单位，name 将取值转为文字
+func describeSpansEn(ss []span, unit string, name func(uint64) string) string {
+	var items []string
+	for _, s := range expandShort(ss) {
+		switch {
+		case s.start == s.end:
+			items = append(items, name(s.start))
+		case s.step == 1:
+			items = append(items, name(s.start)+" through "+name(s.end))
+		default:
+			items = append(items, fmt.Sprintf("every %d %ss from %s through %s", s.step, unit, name(s.start), name(s.end)))
+		}
+	}
+	return joinEn(items, "and")
+}
+
+// expandShort 多个区间组成列表时，将取值不超过 4 个的间隔区间展开为单个值，
+// 如 0,10,20,40 描述为 0、10、20、40 而不是 0 至 20 每 10 秒、40
+func expandShort(ss []span) []span {
+	if len(ss) < 2 {
+		return ss
+	}
+	res := make([]span, 0, len(ss))
+	for _, s := range ss {
+		if s.step > 1 && (s.end-s.start)/s.step < 4 {
+			for v := s.start; v <= s.end; v += s.step {
+				res = append(res, span{v, v, 1})
+			}
+			continue
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// expandSteps 将间隔区间展开为单个值，用于取值很少的字段，如星期 1/2 描述为周一、周三、周五
+func expandSteps(ss []span) []span {
+	res := make([]span, 0, len(ss))
+	for _, s := range ss {
+		for v := s.start; s.step > 1 && v <= s.end; v += s.step {
+			res = append(res, span{v, v, 1})
+		}
+		if s.step <= 1 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// pluralEn 英文单复数
+func pluralEn(n uint64, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// clock 格式化时间 08:30 或 08:30:15
+func clock(h, m, s uint64) string {
+	if s == 0 {
+		return fmt.Sprintf("%02d:%02d", h, m)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+// describeTimeEn 用英文描述 TimeSchedule
+func describeTimeEn(t *TimeSchedule) string {
+	var parts []string
+	hour, min, sec := t.timeFields()
+	h, hOk := hour.single()
+	m, mOk := min.single()
+	s, sOk := sec.single()
+
+	switch {
+	case hOk && mOk && sOk:
+		parts = append(parts, "at "+clock(h, m, s))
+	case mOk && sOk && allSingle(hour.spans()):
+		var times []string
+		for _, sp := range hour.spans() {
+			times = append(times, clock(sp.start, m, s))
+		}
+		parts = append(parts, "at "+joinEn(times, "and"))
+	default:
+		parts = append(parts, describeMinuteEn(hour, min, sec)...)
+		if !hour.full() {
+			parts = append(parts, describeHourEn(hour, mOk && m == 0 && sOk && s == 0))
+		}
+	}
+
+	parts = append(parts, describeDaysEn(t)...)
+
+	if month := (fieldMask{t.month, 1, 12}); !month.full() {
+		parts = append(parts, "only in "+describeSpansEn(month.spans(), "month", func(v uint64) string { return monthNamesEn[v] }))
+	}
+	if t.year != RangeYear {
+		parts = append(parts, "only in "+describeSpansEn(spans(t.year, 0, 63), "year", func(v uint64) string {
+			return strconv.Itoa(StartYear + int(v))
+		}))
+	}
+
+	res := strings.Join(parts, ", ")
+	res = strings.ToUpper(res[:1]) + res[1:]
+	if t.loc != nil && t.loc != time.Local {
+		res += " (" + t.loc.String() + ")"
+	}
+	return res
+}
+
+// allSingle 是否都是单个取值
+func allSingle(ss []span) bool {
+	for _, s := range ss {
+		if s.start != s.end {
+			return false
+		}
+	}
+	return true
+}
+
+// describeMinuteEn 用英文描述分、秒，hour 用于判断是否每小时执行
+func describeMinuteEn(hour, min, sec fieldMask) []string {
+	var parts []string
+	s, sOk := sec.single()
+	m, mOk := min.single()
+
+	switch {
+	case sec.full():
+		parts = append(parts, "every second")
+	case sOk && s == 0:
+	case sOk:
+		parts = append(parts, fmt.Sprintf("at %s past the minute", pluralEn(s, "second")))
+	default:
+		if step, ok := sec.every(); ok {
+			parts = append(parts, fmt.Sprintf("every %d seconds", step))
+		} else {
+			parts = append(parts, "at seconds "+describeSpansEn(sec.spans(), "second", uintString)+" past the minute")
+		}
+	}
+
+	switch {
+	case min.full():
+		if !sec.full() {
+			parts = append(parts, "every minute")
+		}
+	case mOk && m == 0 && sOk && s == 0:
+		if hour.full() {
+			parts = append(parts, "every hour")
+		}
+	case mOk:
+		parts = append(parts, fmt.Sprintf("at %s past the hour", pluralEn(m, "minute")))
+	default:
+		if step, ok := min.every(); ok {
+			parts = append(parts, fmt.Sprintf("every %d minutes", step))
+		} else {
+			parts = append(parts, "at minutes "+describeSpansEn(min.spans(), "minute", uintString)+" past the hour")
+		}
+	}
+	return parts
+}
+
+// describeHourEn 用英文描述小时，onHour 表示只在整点执行
+func describeHourEn(hour fieldMask, onHour bool) string {
+	if step, ok := hour.every(); ok {
+		return fmt.Sprintf("every %d hours", step)
+	}
+
+	ss := hour.spans()
+	if len(ss) == 1 && ss[0].step > 1 {
+		return fmt.Sprintf("every %d hours between %s and %s", ss[0].step, clock(ss[0].start, 0, 0), clock(ss[0].end, 0, 0))
+	}
+
+	if runs := hourRuns(hour); runs != nil {
+		ss = runs
+	}
+
+	var items []string
+	for _, s := range ss {
+		switch {
+		case s.start == s.end && onHour:
+			items = append(items, "at "+clock(s.start, 0, 0))
+		case s.start == s.end:
+			items = append(items, fmt.Sprintf("between %s and %s", clock(s.start, 0, 0), clock(s.start, 59, 0)))
+		case s.step == 1 && onHour:
+			items = append(items, fmt.Sprintf("every hour between %s and %s", clock(s.start, 0, 0), clock(s.end, 0, 0)))
+		case s.step == 1:
+			items = append(items, fmt.Sprintf("between %s and %s", clock(s.start, 0, 0), clock(s.end, 59, 0)))
+		default:
+			items = append(items, fmt.Sprintf("every %d hours between %s and %s", s.step, clock(s.start, 0, 0), clock(s.end, 0, 0)))
+		}
+	}
+	return joinEn(items, "and")
+}
+
+// hourRuns 将小时合并为连续的区间，跨过午夜的区间合并为一个(end 小于 start)，如 22-2 为 22 点至次日 2 点
+// 有等间隔的取值时返回 nil
+func hourRuns(hour fieldMask) []span {
+	var runs []span
+	for _, s := range hour.spans() {
+		if s.step > 1 {
+			return nil
+		}
+		if n := len(runs); n > 0 && runs[n-1].end+1 == s.start {
+			runs[n-1].end = s.end
+			continue
+		}
+		runs = append(runs, s)
+	}
+	if n := len(runs); n > 1 && runs[0].start == hour.min && runs[n-1].end == hour.max {
+		runs[n-1].end = runs[0].end
+		runs = runs[1:]
+	}
+	return runs
+}
+
+// describeDaysEn 用英文描述日期和星期
+func describeDaysEn(t *TimeSchedule) []string {
+	var dayItems, weekItems []string
+
+	day := fieldMask{t.day, 1, 31}
+	if !day.full() || t.hasDayRule() {
+		if t.day&RangeDay != 0 {
+			if day.full() {
+				dayItems = append(dayItems, "every day")
+			} else if v, ok := day.single(); ok {
+				dayItems = append(dayItems, fmt.Sprintf("on day %d of the month", v))
+			} else if step, ok := day.every(); ok {
+				dayItems = append(dayItems, fmt.Sprintf("every %d days", step))
+			} else {
+				dayItems = append(dayItems, "on days "+describeSpansEn(day.spans(), "day", uintString)+" of the month")
+			}
+		}
+		for n := findBit(t.lastDay, 0, 30); n <= 30; n = findBit(t.lastDay, n+1, 30) {
+			if n == 0 {
+				dayItems = append(dayItems, "on the last day of the month")
+			} else {
+				dayItems = append(dayItems, fmt.Sprintf("%s before the last day of the month", pluralEn(n, "day")))
+			}
+		}
+		if t.lastWeekday {
+			dayItems = append(dayItems, "on the last weekday of the month")
+		}
+		for n := findBit(t.nearestWeekday, 1, 31); n <= 31; n = findBit(t.nearestWeekday, n+1, 31) {
+			dayItems = append(dayItems, fmt.Sprintf("on the weekday nearest day %d of the month", n))
+		}
+	}
+
+	week := fieldMask{t.weekDay, 0, 6}
+	if !week.full() || t.hasWeekDayRule() {
+		if t.weekDay&RangeWeekDay != 0 {
+			weekItems = append(weekItems, "only on "+describeSpansEn(expandSteps(week.spans()), "day", func(v uint64) string {
+				return time.Weekday(v).String()
+			}))
+		}
+		for w, ks := range t.nthWeekDay {
+			for k := uint64(1); k <= 5; k++ {
+				if ks&(1<<k) > 0 {
+					weekItems = append(weekItems, fmt.Sprintf("on the %s %s of the month", ordinalsEn[k], time.Weekday(w)))
+				}
+			}
+		}
+		for w := findBit(t.lastWeekDay, 0, 6); w <= 6; w = findBit(t.lastWeekDay, w+1, 6) {
+			weekItems = append(weekItems, fmt.Sprintf("on the last %s of the month", time.Weekday(w)))
+		}
+	}
+
+	switch {
+	case len(dayItems) == 0 && len(weekItems) == 0:
+		return nil
+	case len(dayItems) == 0:
+		return []string{joinEn(weekItems, "or")}
+	case len(weekItems) == 0:
+		return []string{joinEn(dayItems, "or")}
+	case t.dayOr:
+		return []string{joinEn(dayItems, "or") + " or " + joinEn(weekItems, "or")}
+	}
+	return []string{joinEn(dayItems, "or"), joinEn(weekItems, "or")}
+}
+
+func uintString(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+// describeSpansZh 用中文描述字段取值，unit 为间隔的单位，name 将取值转为文字
+func describeSpansZh(ss []span, unit string, name func(uint64) string) string {
+	var items []string
+	for _, s := range expandShort(ss) {
+		switch {
+		case s.start == s.end:
+			items = append(items, name(s.start))
+		case s.step == 1:
+			items = append(items, concatZh(name(s.start), "至", name(s.end)))
+		default:
+			items = append(items, concatZh(name(s.start), "至", name(s.end), fmt.Sprintf("每 %d %s", s.step, unit)))
+		}
+	}
+	return strings.Join(items, "、")
+}
+
+// concatZh 连接中文描述，只在汉字与数字、字母相邻处加空格，如 8 点至 20 点
+func concatZh(items ...string) string {
+	var b strings.Builder
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(b.String())
+			next, _ := utf8.DecodeRuneInString(item)
+			if (prev < utf8.RuneSelf) != (next < utf8.RuneSelf) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(item)
+	}
+	return b.String()
+}
+
+// describeTimeZh 用中文描述 TimeSchedule
+func describeTimeZh(t *TimeSchedule) string {
+	var parts []string
+	if t.year != RangeYear {
+		parts = append(parts, describeSpansZh(spans(t.year, 0, 63), "年", func(v uint64) string {
+			return fmt.Sprintf("%d 年", StartYear+int(v))
+		}))
+	}
+	// 月份有限制时日期不再加 "每月"，如 1 月 1 日
+	every := "每月"
+	if month := (fieldMask{t.month, 1, 12}); !month.full() {
+		parts = append(parts, describeSpansZh(month.spans(), "个月", func(v uint64) string {
+			return fmt.Sprintf("%d 月", v)
+		}))
+		every = ""
+	}
+	parts = append(parts, describeDaysZh(t, every)...)
+
+	hour, min, sec := t.timeFields()
+	h, hOk := hour.single()
+	m, mOk := min.single()
+	s, sOk := sec.single()
+	if len(parts) == 0 && mOk && sOk && allSingle(hour.spans()) {
+		parts = append(parts, "每天")
+	}
+	switch {
+	case hOk && mOk && sOk:
+		parts = append(parts, clock(h, m, s))
+	case mOk && sOk && allSingle(hour.spans()):
+		var times []string
+		for _, sp := range hour.spans() {
+			times = append(times, clock(sp.start, m, s))
+		}
+		parts = append(parts, strings.Join(times, "、"))
+	default:
+		parts = append(parts, describeClockZh(hour, min, sec))
+	}
+
+	res := strings.Join(parts, " ")
+	if t.loc != nil && t.loc != time.Local {
+		res += " (" + t.loc.String() + ")"
+	}
+	return res
+}
+
+// describeClockZh 用中文描述时、分、秒，如: 8 点至 20 点每 3 小时的第 2 分钟
+func describeClockZh(hour, min, sec fieldMask) string {
+	type part struct {
+		field fieldMask
+		unit  string
+		name  func(uint64) string
+	}
+	fields := []part{
+		{hour, "小时", func(v uint64) string { return fmt.Sprintf("%d 点", v) }},
+		{min, "分钟", func(v uint64) string { return fmt.Sprintf("第 %d 分钟", v) }},
+		{sec, "秒", func(v uint64) string { return fmt.Sprintf("第 %d 秒", v) }},
+	}
+
+	// 去掉末尾取值为 0 的字段，如 0 0 */2 * * * 描述为每 2 小时
+	for len(fields) > 1 {
+		if v, ok := fields[len(fields)-1].field.single(); !ok || v != 0 {
+			break
+		}
+		fields = fields[:len(fields)-1]
+	}
+
+	var items []string
+	for i, f := range fields {
+		// 没有限制的字段，后面有更细的限制时省略，如每 15 分钟不必描述为每小时的每 15 分钟
+		if f.field.full() {
+			if i+1 < len(fields) && !isSingleList(fields[i+1].field) {
+				continue
+			}
+			items = append(items, "每"+f.unit)
+			continue
+		}
+		if step, ok := f.field.every(); ok {
+			items = append(items, fmt.Sprintf("每 %d %s", step, f.unit))
+			continue
+		}
+		ss := f.field.spans()
+		if len(ss) == 1 && ss[0].step > 1 {
+			items = append(items, concatZh(f.name(ss[0].start), "至", f.name(ss[0].end), fmt.Sprintf("每 %d %s", ss[0].step, f.unit)))
+			continue
+		}
+		items = append(items, describeSpansZh(ss, f.unit, f.name))
+	}
+
+	// 只剩小时的区间时表示整点执行，如 0 0 9-17 * * * 描述为 9 点至 17 点整点
+	if len(fields) == 1 && !isSingleList(hour) && !hour.full() {
+		if _, ok := hour.every(); !ok {
+			items = append(items, "整点")
+			return strings.Join(items, "")
+		}
+	}
+	return strings.Join(items, "的")
+}
+
+// isSingleList 字段是否由单个取值组成
+func isSingleList(f fieldMask) bool {
+	return !f.full() && allSingle(expandShort(f.spans()))
+}
+
+// describeDaysZh 用中文描述日期和星期，every 为日期的前缀
+func describeDaysZh(t *TimeSchedule, every string) []string {
+	var dayItems, weekItems []string
+
+	day := fieldMask{t.day, 1, 31}
+	if !day.full() || t.hasDayRule() {
+		if t.day&RangeDay != 0 {
+			if day.full() {
+				dayItems = append(dayItems, "每天")
+			} else {
+				dayItems = append(dayItems, concatZh(every, describeSpansZh(day.spans(), "天", func(v uint64) string {
+					return fmt.Sprintf("%d 日", v)
+				})))
+			}
+		}
+		for n := findBit(t.lastDay, 0, 30); n <= 30; n = findBit(t.lastDay, n+1, 30) {
+			if n == 0 {
+				dayItems = append(dayItems, every+"最后一天")
+			} else {
+				dayItems = append(dayItems, fmt.Sprintf("%s倒数第 %d 天", every, n+1))
+			}
+		}
+		if t.lastWeekday {
+			dayItems = append(dayItems, every+"最后一个工作日")
+		}
+		for n := findBit(t.nearestWeekday, 1, 31); n <= 31; n = findBit(t.nearestWeekday, n+1, 31) {
+			dayItems = append(dayItems, fmt.Sprintf("%s离 %d 日最近的工作日", every, n))
+		}
+	}
+
+	week := fieldMask{t.weekDay, 0, 6}
+	if !week.full() || t.hasWeekDayRule() {
+		if t.weekDay&RangeWeekDay != 0 {
+			weekItems = append(weekItems, describeSpansZh(expandSteps(week.spans()), "天", func(v uint64) string {
+				return weekNamesZh[v]
+			}))
+		}
+		for w, ks := range t.nthWeekDay {
+			for k := uint64(1); k <= 5; k++ {
+				if ks&(1<<k) > 0 {
+					weekItems = append(weekItems, fmt.Sprintf("%s第 %d 个%s", every, k, weekNamesZh[w]))
+				}
+			}
+		}
+		for w := findBit(t.lastWeekDay, 0, 6); w <= 6; w = findBit(t.lastWeekDay, w+1, 6) {
+			weekItems = append(weekItems, every+"最后一个"+weekNamesZh[w])
+		}
+	}
+
+	switch {
+	case len(dayItems) == 0 && len(weekItems) == 0:
+		return nil
+	case len(dayItems) == 0:
+		return []string{strings.Join(weekItems, "或")}
+	case len(weekItems) == 0:
+		return []string{strings.Join(dayItems, "或")}
+	case t.dayOr:
+		return []string{strings.Join(dayItems, "或") + "或" + strings.Join(weekItems, "或")}
+	}
+	return []string{strings.Join(dayItems, "或") + "且为" + strings.Join(weekItems, "或")}
+}
+
+// formatDurationEn 用英文描述时长，如 1 hour 30 minutes
+func formatDurationEn(d time.Duration) string {
+	var items []string
+	for _, u := range durationUnits {
+		if n := d / u.d; n > 0 {
+			items = append(items, pluralEn(uint64(n), u.en))
+			d -= n * u.d
+		}
+	}
+	if d > 0 || len(items) == 0 {
+		items = append(items, d.String())
+	}
+	return strings.Join(items, " ")
+}
+
+// formatDurationZh 用中文描述时长，如 1 小时 30 分钟
+func formatDurationZh(d time.Duration) string {
+	var items []string
+	for _, u := range durationUnits {
+		if n := d / u.d; n > 0 {
+			items = append(items, fmt.Sprintf("%d %s", n, u.zh))
+			d -= n * u.d
+		}
+	}
+	if d > 0 || len(items) == 0 {
+		items = append(items, d.String())
+	}
+	return strings.Join(items, " ")
+}
+
+// durationUnits 描述时长使用的单位
+var durationUnits = []struct {
+	d      time.Duration
+	en, zh string
+}{
+	{24 * time.Hour, "day", "天"},
+	{time.Hour, "hour", "小时"},
+	{time.Minute, "minute", "分钟"},
+	{time.Second, "second", "秒"},
+}
@@ -0,0 +1,90 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Describe(t *testing.T) {
+	data := []struct {
+		name string
+		expr string
+		zh   string
+		en   string
+	}{
+		{"每秒", "* * * * * *", "每秒", "Every second"},
+		{"每分钟", "0 * * * * *", "每分钟", "Every minute"},
+		{"间隔分钟", "0 */15 * * * *", "每 15 分钟", "Every 15 minutes"},
+		{"间隔小时", "0 0 */2 * * *", "每 2 小时", "Every 2 hours"},
+		{"小时区间间隔", "0 2 8-20/3 * * *", "8 点至 20 点每 3 小时的第 2 分钟", "At 2 minutes past the hour, every 3 hours between 08:00 and 20:00"},
+		{"整点区间", "0 0 9-17 * * *", "9 点至 17 点整点", "Every hour between 09:00 and 17:00"},
+		{"跨午夜整点", "0 0 22-2 * * *", "0 点至 2 点、22 点、23 点整点", "Every hour between 22:00 and 02:00"},
+		{"跨午夜分钟", "0 */5 22-2 * * *", "0 点至 2 点、22 点、23 点的每 5 分钟", "Every 5 minutes, between 22:00 and 02:59"},
+		{"整点和区间", "0 0 9,14-16 * * *", "9 点、14 点至 16 点整点", "At 09:00 and every hour between 14:00 and 16:00"},
+		{"间隔星期", "0 0 9 * * 1/2", "周一、周三、周五 09:00", "At 09:00, only on Monday, Wednesday and Friday"},
+		{"秒列表", "0,10,20,40 * * * * *", "每分钟的第 0 秒、第 10 秒、第 20 秒、第 40 秒", "At seconds 0, 10, 20 and 40 past the minute, every minute"},
+		{"多个时间", "0 0 8,12,18 * * *", "每天 08:00、12:00、18:00", "At 08:00, 12:00 and 18:00"},
+		{"工作日", "0 30 9 * * MON-FRI", "周一至周五 09:30", "At 09:30, only on Monday through Friday"},
+		{"日期列表", "0 0 9 1,15 * *", "每月 1 日、15 日 09:00", "At 09:00, on days 1 and 15 of the month"},
+		{"月份和年", "0 0 0 1 1 * 2027-2029", "2027 年至 2029 年 1 月 1 日 00:00", "At 00:00, on day 1 of the month, only in January, only in 2027 through 2029"},
+		{"月末", "0 0 0 L * ?", "每月最后一天 00:00", "At 00:00, on the last day of the month"},
+		{"月末前", "0 0 0 L-3 * ?", "每月倒数第 4 天 00:00", "At 00:00, 3 days before the last day of the month"},
+		{"最近工作日", "0 0 9 15W * ?", "每月离 15 日最近的工作日 09:00", "At 09:00, on the weekday nearest day 15 of the month"},
+		{"第几个星期", "0 0 12 ? * 5#2", "每月第 2 个周五 12:00", "At 12:00, on the second Friday of the month"},
+		{"最后一个星期", "0 0 0 ? * 5L", "每月最后一个周五 00:00", "At 00:00, on the last Friday of the month"},
+		{"日期且星期", "0 0 0 13 * 5", "每月 13 日且为周五 00:00", "At 00:00, on day 13 of the month, only on Friday"},
+		{"时区", "CRON_TZ=Asia/Shanghai 0 0 9 * * *", "每天 09:00 (Asia/Shanghai)", "At 09:00 (Asia/Shanghai)"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := Describe(s, LangZh); get != p.zh {
+				t.Errorf("zh want: %s, get: %s", p.zh, get)
+			}
+			if get := Describe(s, LangEn); get != p.en {
+				t.Errorf("en want: %s, get: %s", p.en, get)
+			}
+		})
+	}
+}
+
+func Test_DescribeDayOr(t *testing.T) {
+	s, err := NewParser(WithSeconds(), WithDayOr()).Parse("0 0 0 13 * 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get, want := Describe(s, LangZh), "每月 13 日或周五 00:00"; get != want {
+		t.Errorf("want: %s, get: %s", want, get)
+	}
+	if get, want := Describe(s, LangEn), "At 00:00, on day 13 of the month or only on Friday"; get != want {
+		t.Errorf("want: %s, get: %s", want, get)
+	}
+}
+
+func Test_DescribeOther(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		name string
+		s    Scheduler
+		lang Lang
+		want string
+	}{
+		{"间隔-中文", &DurationSchedule{start: start, frequency: 90 * time.Minute}, LangZh, "从 2026-01-01 00:00:00 UTC 开始每隔 1 小时 30 分钟"},
+		{"间隔-英文", &DurationSchedule{start: start, frequency: 90 * time.Minute}, LangEn, "Every 1 hour 30 minutes, starting at 2026-01-01 00:00:00 UTC"},
+		{"固定时间-中文", &FixSchedule{rTime: start}, LangZh, "在 2026-01-01 00:00:00 UTC 执行一次"},
+		{"固定时间-英文", &FixSchedule{rTime: start}, LangEn, "Once at 2026-01-01 00:00:00 UTC"},
+		{"未知语言", &FixSchedule{rTime: start}, Lang("fr"), "在 2026-01-01 00:00:00 UTC 执行一次"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			if get := Describe(p.s, p.lang); get != p.want {
+				t.Errorf("want: %s, get: %s", p.want, get)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package corn
+
+import (
+	"strings"
+	"time"
+)
+
+// DSTGap 夏令时开始(时钟拨快)时，不存在的本地时间的处理方式
+type DSTGap int
+
+const (
+	// DSTShift 顺延拨快的时长后执行，如美国东部时间 02:30 在 03:30(EDT) 执行，与 RFC 5545 相同
+	DSTShift DSTGap = iota
+	// DSTSkip 跳过不存在的时间
+	DSTSkip
+)
+
+// DSTOverlap 夏令时结束(时钟拨回)时，重复出现的本地时间的处理方式
+type DSTOverlap int
+
+const (
+	// DSTOnce 只在第一次出现时执行，如美国东部时间 01:30 只在 01:30(EDT) 执行，与 RFC 5545 相同
+	DSTOnce DSTOverlap = iota
+	// DSTTwice 两次都执行，如美国东部时间 01:30 在 01:30(EDT) 和 01:30(EST) 各执行一次
+	DSTTwice
+)
+
+// DSTPolicy 夏令时切换时的执行策略，零值为 {DSTShift, DSTOnce}
+// 与执行时间是否被切换影响无关的调度器(如 UTC、Asia/Shanghai)不受影响
+type DSTPolicy struct {
+	Gap     DSTGap
+	Overlap DSTOverlap
+}
+
+// dstWords CRON_DST= 前缀的取值
+var dstWords = map[string]func(d *DSTPolicy){
+	"shift": func(d *DSTPolicy) { d.Gap = DSTShift },
+	"skip":  func(d *DSTPolicy) { d.Gap = DSTSkip },
+	"once":  func(d *DSTPolicy) { d.Overlap = DSTOnce },
+	"twice": func(d *DSTPolicy) { d.Overlap = DSTTwice },
+}
+
+// parseDST 解析 CRON_DST= 前缀的取值，如 skip、twice、skip,twice
+func parseDST(value string, d *DSTPolicy) bool {
+	for _, word := range strings.Split(value, ",") {
+		set, ok := dstWords[strings.ToLower(word)]
+		if !ok {
+			return false
+		}
+		set(d)
+	}
+	return true
+}
+
+// prefix 格式化为 CRON_DST= 前缀的取值，只包含不是默认值的部分，都是默认值时返回空字符串
+func (d DSTPolicy) prefix() string {
+	var words []string
+	if d.Gap == DSTSkip {
+		words = append(words, "skip")
+	}
+	if d.Overlap == DSTTwice {
+		words = append(words, "twice")
+	}
+	return strings.Join(words, ",")
+}
+
+// instants 本地时间 year-month-day h:m:s 在 t.loc 中对应的执行时刻，按 t.dst 处理夏令时切换:
+// 不存在的时间(时钟拨快)顺延时返回一个时刻，跳过时返回两个零时；
+// 重复的时间(时钟拨回)返回先出现的时刻，两次都执行时第二个返回值为后出现的时刻，否则为零时
+func (t *TimeSchedule) instants(year int, month time.Month, day, h, m, s int) (time.Time, time.Time) {
+	r := time.Date(year, month, day, h, m, s, 0, t.loc)
+
+	// 前后一天的偏移相同时没有切换
+	_, before := r.Add(-24 * time.Hour).Zone()
+	_, after := r.Add(24 * time.Hour).Zone()
+	if before == after {
+		return r, time.Time{}
+	}
+
+	// 分别按切换前后的偏移计算，偏移与所在时刻一致的为有效时刻
+	wall := time.Date(year, month, day, h, m, s, 0, time.UTC)
+	valid := func(offset int) (time.Time, bool) {
+		c := wall.Add(-time.Duration(offset) * time.Second).In(t.loc)
+		_, o := c.Zone()
+		return c, o == offset
+	}
+	first, ok1 := valid(before)
+	second, ok2 := valid(after)
+
+	switch {
+	case ok1 && ok2:
+		// 时钟拨回，重复的时间
+		if first.After(second) {
+			first, second = second, first
+		}
+		if t.dst.Overlap == DSTTwice {
+			return first, second
+		}
+		return first, time.Time{}
+	case ok1:
+		return first, time.Time{}
+	case ok2:
+		return second, time.Time{}
+	}
+
+	// 时钟拨快，不存在的时间按切换前的偏移计算即为顺延
+	if t.dst.Gap == DSTSkip {
+		return time.Time{}, time.Time{}
+	}
+	return first, time.Time{}
+}
+
+// dstWindow 查找时钟拨回的时间范围，大于各时区切换的时长
+const dstWindow = 3 * time.Hour
+
+// fallBack r 前后 dstWindow 内时钟拨回时返回拨回的时长，否则返回 0
+func (t *TimeSchedule) fallBack(r time.Time) time.Duration {
+	_, before := r.Add(-dstWindow).In(t.loc).Zone()
+	_, after := r.Add(dstWindow).In(t.loc).Zone()
+	if before > after {
+		return time.Duration(before-after) * time.Second
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TimeScheduleDST(t *testing.T) {
+	data := []struct {
+		name string
+		zone string
+		expr string
+		from string
+		want []string
+	}{
+		// 纽约 2019-03-10 02:00 拨快到 03:00，2019-11-03 02:00 拨回到 01:00
+		{"纽约拨快顺延", "America/New_York", "0 30 2 * * *", "2019-03-09 12:00", []string{"2019-03-10 03:30 EDT", "2019-03-11 02:30 EDT"}},
+		{"纽约拨快跳过", "America/New_York", "CRON_DST=skip 0 30 2 * * *", "2019-03-09 12:00", []string{"2019-03-11 02:30 EDT", "2019-03-12 02:30 EDT"}},
+		{"纽约拨回一次", "America/New_York", "0 30 1 * * *", "2019-11-02 12:00", []string{"2019-11-03 01:30 EDT", "2019-11-04 01:30 EST"}},
+		{"纽约拨回两次", "America/New_York", "CRON_DST=twice 0 30 1 * * *", "2019-11-02 12:00", []string{"2019-11-03 01:30 EDT", "2019-11-03 01:30 EST", "2019-11-04 01:30 EST"}},
+		{"纽约拨回每半小时", "America/New_York", "CRON_DST=twice 0 0,30 1-2 3 11 *", "2019-11-02 12:00",
+			[]string{"2019-11-03 01:00 EDT", "2019-11-03 01:30 EDT", "2019-11-03 01:00 EST", "2019-11-03 01:30 EST", "2019-11-03 02:00 EST", "2019-11-03 02:30 EST"}},
+
+		// 伦敦 2019-03-31 01:00 拨快到 02:00，2019-10-27 02:00 拨回到 01:00
+		{"伦敦拨快", "Europe/London", "0 15 1 * * *", "2019-03-30 12:00", []string{"2019-03-31 02:15 BST", "2019-04-01 01:15 BST"}},
+		{"伦敦拨回", "Europe/London", "CRON_DST=skip,twice 0 15 1 * * *", "2019-10-26 12:00", []string{"2019-10-27 01:15 BST", "2019-10-27 01:15 GMT", "2019-10-28 01:15 GMT"}},
+
+		// 柏林 2019-10-27 03:00 拨回到 02:00
+		{"柏林拨回一次", "Europe/Berlin", "0 0 2 * * *", "2019-10-26 12:00", []string{"2019-10-27 02:00 CEST", "2019-10-28 02:00 CET"}},
+
+		// 悉尼 2019-04-07 03:00 拨回到 02:00，2019-10-06 02:00 拨快到 03:00
+		{"悉尼拨回", "Australia/Sydney", "CRON_DST=twice 0 30 2 * * *", "2019-04-06 12:00", []string{"2019-04-07 02:30 AEDT", "2019-04-07 02:30 AEST", "2019-04-08 02:30 AEST"}},
+		{"悉尼拨快", "Australia/Sydney", "CRON_DST=skip 0 30 2 * * *", "2019-10-05 12:00", []string{"2019-10-07 02:30 AEDT"}},
+
+		// 豪勋爵岛 2019-10-06 02:00 拨快 30 分钟到 02:30
+		{"半小时拨快", "Australia/Lord_Howe", "0 15 2 * * *", "2019-10-05 12:00", []string{"2019-10-06 02:45 +11", "2019-10-07 02:15 +11"}},
+
+		// 圣地亚哥 2019-09-08 00:00 拨快到 01:00，2019-04-07 00:00 拨回到 2019-04-06 23:00
+		{"零点拨快", "America/Santiago", "0 0 0 * * *", "2019-09-07 12:00", []string{"2019-09-08 01:00 -03", "2019-09-09 00:00 -03"}},
+		{"零点拨快跳过", "America/Santiago", "CRON_DST=skip 0 0 0 * * *", "2019-09-07 12:00", []string{"2019-09-09 00:00 -03"}},
+		{"跨天拨回", "America/Santiago", "CRON_DST=twice 0 30 23 * * *", "2019-04-06 12:00", []string{"2019-04-06 23:30 -03", "2019-04-06 23:30 -04", "2019-04-07 23:30 -04"}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(p.zone)
+			if err != nil {
+				t.Skip(err)
+			}
+			s, err := Parse("CRON_TZ=" + p.zone + " " + p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, _ := time.ParseInLocation("2006-01-02 15:04", p.from, loc)
+			get := NextN(s, from, len(p.want))
+			if len(get) != len(p.want) {
+				t.Fatalf("want: %v, get: %v", p.want, get)
+			}
+			for i := range get {
+				if f := get[i].In(loc).Format("2006-01-02 15:04 MST"); f != p.want[i] {
+					t.Errorf("第 %d 次 want: %s, get: %s", i, p.want[i], f)
+				}
+			}
+
+			// 向前查找得到相同的执行时间
+			for i := len(get) - 1; i > 0; i-- {
+				if prev := Prev(s, get[i]); !prev.Equal(get[i-1]) {
+					t.Errorf("Prev(%s) want: %s, get: %s", get[i], get[i-1], prev)
+				}
+			}
+		})
+	}
+}
+
+// 切换当天从任意时间开始查找，Next 和 Prev 都不会跳过或重复执行时间
+func Test_TimeScheduleDSTFrom(t *testing.T) {
+	data := []struct {
+		zone   string
+		expr   string
+		spring string
+		fall   string
+		counts [2]int
+	}{
+		{"America/New_York", "0 */15 * * * *", "2019-03-10", "2019-11-03", [2]int{92, 96}},
+		{"America/New_York", "CRON_DST=skip,twice 0 */15 * * * *", "2019-03-10", "2019-11-03", [2]int{92, 100}},
+		{"Europe/Berlin", "0 0 * * * *", "2019-03-31", "2019-10-27", [2]int{23, 24}},
+		{"Europe/Berlin", "CRON_DST=twice 0 0 * * * *", "2019-03-31", "2019-10-27", [2]int{23, 25}},
+		{"Australia/Lord_Howe", "CRON_DST=twice 0 */10 * * * *", "2019-10-06", "2019-04-07", [2]int{141, 147}},
+	}
+
+	for _, p := range data {
+		t.Run(p.zone+" "+p.expr, func(t *testing.T) {
+			loc, err := time.LoadLocation(p.zone)
+			if err != nil {
+				t.Skip(err)
+			}
+			s, err := Parse("CRON_TZ=" + p.zone + " " + p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, day := range []string{p.spring, p.fall} {
+				start, _ := time.ParseInLocation("2006-01-02", day, loc)
+				end := start.AddDate(0, 0, 1)
+				all := Between(s, start, end)
+				if len(all) != p.counts[i] {
+					t.Fatalf("%s want: %d, get: %d", day, p.counts[i], len(all))
+				}
+
+				// 每 7 分钟开始查找，结果应为当天执行时间中相邻的两个
+				for from := start; from.Before(all[len(all)-1]); from = from.Add(7 * time.Minute) {
+					k := 0
+					for k < len(all) && !all[k].After(from) {
+						k++
+					}
+					if next := s.Next(from); !next.Equal(all[k]) {
+						t.Fatalf("Next(%s) want: %s, get: %s", from, all[k], next)
+					}
+					if k > 0 && !all[k-1].Equal(from) {
+						if prev := Prev(s, from); !prev.Equal(all[k-1]) {
+							t.Fatalf("Prev(%s) want: %s, get: %s", from, all[k-1], prev)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_DSTPrefix(t *testing.T) {
+	s, err := NewParser(WithSeconds(), WithDST(DSTPolicy{Gap: DSTSkip})).Parse("CRON_DST=twice 0 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := s.(*TimeSchedule)
+	if ts.dst != (DSTPolicy{Gap: DSTSkip, Overlap: DSTTwice}) {
+		t.Errorf("get: %+v", ts.dst)
+	}
+	if get := ts.String(); get != "CRON_DST=skip,twice 0 0 9 * * *" {
+		t.Errorf("get: %s", get)
+	}
+	if s, err = Parse("CRON_DST=skip @daily"); err != nil || s.(*TimeSchedule).dst.Gap != DSTSkip {
+		t.Errorf("描述符 get: %v %v", s, err)
+	}
+
+	_, err = Parse("CRON_DST=never 0 0 9 * * *")
+	if pe, ok := err.(*ParseError); !ok || pe.Token != "CRON_DST=never" || pe.Reason != "CRON_DST expects shift, skip, once or twice" {
+		t.Errorf("err: %v", err)
+	}
+}
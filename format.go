@@ -0,0 +1,311 @@
+package corn
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// span 取值区间 start-end/step，start == end 表示单个值
+type span struct {
+	start, end, step uint64
+}
+
+// spans 将 mask 在 [min, max] 内的 bit 位压缩为区间
+// 至少 3 个等间隔的取值才会合并为区间
+func spans(mask, min, max uint64) []span {
+	var values []uint64
+	for i := findBit(mask, min, max); i <= max; i = findBit(mask, i+1, max) {
+		values = append(values, i)
+	}
+
+	var res []span
+	for i := 0; i < len(values); {
+		j := i
+		if i+2 < len(values) {
+			step := values[i+1] - values[i]
+			for j+1 < len(values) && values[j+1]-values[j] == step {
+				j++
+			}
+			if j-i >= 2 {
+				res = append(res, span{values[i], values[j], step})
+				i = j + 1
+				continue
+			}
+		}
+		res = append(res, span{values[i], values[i], 1})
+		i++
+	}
+	return res
+}
+
+// errEmptyField 字段没有任何取值，无法表示为表达式
+var errEmptyField = errors.New("cron: schedule has a field without any value")
+
+// formatField 将 bit 位格式化为规范的字段，连续或等间隔的取值压缩为区间，没有限制时为 *
+func formatField(mask uint64, b bounds) string {
+	lo, hi := b.min-b.offset, b.last()-b.offset
+	if (fieldMask{mask, lo, hi}).full() {
+		return "*"
+	}
+
+	var items []string
+	for _, s := range spans(mask, lo, hi) {
+		start, end := s.start+b.offset, s.end+b.offset
+		switch {
+		case s.start == s.end:
+			items = append(items, fmt.Sprint(start))
+		case s.step == 1:
+			items = append(items, fmt.Sprintf("%d-%d", start, end))
+		case s.end+s.step > hi && s.start == lo:
+			items = append(items, fmt.Sprintf("*/%d", s.step))
+		case s.end+s.step > hi:
+			items = append(items, fmt.Sprintf("%d/%d", start, s.step))
+		default:
+			items = append(items, fmt.Sprintf("%d-%d/%d", start, end, s.step))
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// formatDay 格式化日期字段，包括 L、L-n、LW、nW
+func (t *TimeSchedule) formatDay() string {
+	var items []string
+	if t.day != 0 {
+		items = append(items, formatField(t.day, days))
+	}
+	for n := findBit(t.lastDay, 0, 30); n <= 30; n = findBit(t.lastDay, n+1, 30) {
+		if n == 0 {
+			items = append(items, "L")
+		} else {
+			items = append(items, fmt.Sprintf("L-%d", n))
+		}
+	}
+	if t.lastWeekday {
+		items = append(items, "LW")
+	}
+	for n := findBit(t.nearestWeekday, 1, 31); n <= 31; n = findBit(t.nearestWeekday, n+1, 31) {
+		items = append(items, fmt.Sprintf("%dW", n))
+	}
+	return strings.Join(items, ",")
+}
+
+// formatWeekDay 格式化星期字段，包括 n#k、nL
+func (t *TimeSchedule) formatWeekDay() string {
+	var items []string
+	if t.weekDay != 0 {
+		items = append(items, formatField(t.weekDay, weekDays))
+	}
+	for w, ks := range t.nthWeekDay {
+		for k := 1; k <= 5; k++ {
+			if ks&(1<<uint(k)) > 0 {
+				items = append(items, fmt.Sprintf("%d#%d", w, k))
+			}
+		}
+	}
+	for w := findBit(t.lastWeekDay, 0, 6); w <= 6; w = findBit(t.lastWeekDay, w+1, 6) {
+		items = append(items, fmt.Sprintf("%dL", w))
+	}
+	return strings.Join(items, ",")
+}
+
+// format 格式化为规范表达式，字段没有任何取值时返回 errEmptyField
+func (t *TimeSchedule) format() (string, error) {
+	var prefixes []string
+	if t.loc != nil && t.loc != time.Local {
+		prefixes = append(prefixes, "CRON_TZ="+t.loc.String())
+	}
+	if t.dayOr {
+		prefixes = append(prefixes, "CRON_DAY_OR=1")
+	}
+	if dst := t.dst.prefix(); dst != "" {
+		prefixes = append(prefixes, "CRON_DST="+dst)
+	}
+
+	fields := []string{
+		formatField(t.second, seconds),
+		formatField(t.min, minutes),
+		formatField(t.hour, hours),
+		t.formatDay(),
+		formatField(t.month, months),
+		t.formatWeekDay(),
+	}
+	if t.year != RangeYear {
+		fields = append(fields, formatField(t.year, years))
+	}
+
+	var err error
+	for _, f := range fields {
+		if f == "" {
+			err = errEmptyField
+		}
+	}
+	return strings.Join(append(prefixes, fields...), " "), err
+}
+
+// String 规范表达式，可以被 Parse 解析为相同的调度器，如:
+//  CRON_TZ=Asia/Shanghai 0 0 9 * * 1-5
+// 时区为 time.Local 时省略时区前缀，年字段没有限制时省略年字段
+// 时区无法按名称加载(如 time.FixedZone)时结果不能被 Parse 解析
+func (t *TimeSchedule) String() string {
+	s, _ := t.format()
+	return s
+}
+
+// MarshalText 实现 encoding.TextMarshaler，内容同 String
+// 时区无法按名称加载(如 time.FixedZone)时返回错误，因为 Parse 无法解析其 CRON_TZ= 前缀
+func (t *TimeSchedule) MarshalText() ([]byte, error) {
+	s, err := t.format()
+	if err != nil {
+		return nil, err
+	}
+	if t.loc != nil && t.loc != time.Local {
+		if _, err := time.LoadLocation(t.loc.String()); err != nil {
+			return nil, fmt.Errorf("cron: time zone %q can not be marshaled: %v", t.loc, err)
+		}
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，使用 Parse 解析
+func (t *TimeSchedule) UnmarshalText(text []byte) error {
+	s, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	ts, ok := s.(*TimeSchedule)
+	if !ok {
+		return notSchedule(text, "TimeSchedule")
+	}
+	*t = *ts
+	return nil
+}
+
+// String 规范表达式，如 @every 1h30m0s from 2019-05-20T00:00:00Z
+// 有执行次数限制时为 ISO 8601 重复时间间隔，如 R5/2019-05-20T00:00:00Z/PT1H30M
+func (d *DurationSchedule) String() string {
+	if d.count > 0 {
+		return fmt.Sprintf("R%d/%s/%s", d.count, d.start.Format(time.RFC3339Nano), formatISODuration(d.frequency))
+	}
+	return fmt.Sprintf("@every %s from %s", d.frequency, d.start.Format(time.RFC3339Nano))
+}
+
+// MarshalText 实现 encoding.TextMarshaler，内容同 String
+func (d *DurationSchedule) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，使用 Parse 或 ParseISO8601 解析
+func (d *DurationSchedule) UnmarshalText(text []byte) error {
+	s, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	ds, ok := s.(*DurationSchedule)
+	if !ok {
+		return notSchedule(text, "DurationSchedule")
+	}
+	*d = *ds
+	return nil
+}
+
+// String 规范表达式，如 @at 2019-05-20T09:00:00+08:00
+func (f *FixSchedule) String() string {
+	return "@at " + f.rTime.Format(time.RFC3339Nano)
+}
+
+// MarshalText 实现 encoding.TextMarshaler，内容同 String
+func (f *FixSchedule) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，使用 Parse 解析
+func (f *FixSchedule) UnmarshalText(text []byte) error {
+	s, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	fs, ok := s.(*FixSchedule)
+	if !ok {
+		return notSchedule(text, "FixSchedule")
+	}
+	*f = *fs
+	return nil
+}
+
+// unmarshalText 解析序列化的表达式，iCalendar 重复规则使用 ParseRRule，
+// ISO 8601 重复时间间隔(R/、Rn/)使用 ParseISO8601，其它使用 Parse
+func unmarshalText(text []byte) (Scheduler, error) {
+	spec := string(text)
+	upper := strings.ToUpper(strings.TrimSpace(spec))
+	for _, prefix := range []string{"DTSTART", "RRULE", "EXDATE", "RDATE", "FREQ="} {
+		if strings.HasPrefix(upper, prefix) {
+			r, err := ParseRRule(spec)
+			if err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+	}
+	if len(upper) > 1 && upper[0] == 'R' && (upper[1] == '/' || upper[1] == '-' || upper[1] >= '0' && upper[1] <= '9') {
+		d, err := ParseISO8601(spec)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	return Parse(spec)
+}
+
+// notSchedule 表达式不是 name 类型的调度器
+func notSchedule(text []byte, name string) error {
+	return &ParseError{Expr: string(text), Field: -1, Offset: -1, Reason: "not a " + name}
+}
+
+// Expr 可以序列化的调度器，用于在配置文件、数据库中保存内置调度器，如:
+//  type Config struct {
+//  	Schedule corn.Expr `json:"schedule"`
+//  }
+// Scheduler 为 nil 时序列化为空字符串，空字符串反序列化为 nil
+type Expr struct {
+	Scheduler
+}
+
+// MarshalText 实现 encoding.TextMarshaler，Scheduler 需要实现 encoding.TextMarshaler
+func (e Expr) MarshalText() ([]byte, error) {
+	if e.Scheduler == nil {
+		return []byte{}, nil
+	}
+	m, ok := e.Scheduler.(encoding.TextMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("cron: %T can not be marshaled", e.Scheduler)
+	}
+	return m.MarshalText()
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，使用 Parse 或 ParseRRule 解析
+func (e *Expr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		e.Scheduler = nil
+		return nil
+	}
+	s, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	e.Scheduler = s
+	return nil
+}
+
+var (
+	_ encoding.TextMarshaler   = new(TimeSchedule)
+	_ encoding.TextUnmarshaler = new(TimeSchedule)
+	_ encoding.TextMarshaler   = new(DurationSchedule)
+	_ encoding.TextUnmarshaler = new(DurationSchedule)
+	_ encoding.TextMarshaler   = new(FixSchedule)
+	_ encoding.TextUnmarshaler = new(FixSchedule)
+	_ encoding.TextMarshaler   = Expr{}
+	_ encoding.TextUnmarshaler = new(Expr)
+)
@@ -0,0 +1,205 @@
+package corn
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_TimeScheduleString(t *testing.T) {
+	data := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"全部", "* * * * * *", "* * * * * *"},
+		{"单个值", "0 30 9 1 1 0", "0 30 9 1 1 0"},
+		{"区间", "0 0-10 17 * * *", "0 0-10 17 * * *"},
+		{"间隔", "0/30 */15 8-20/3 * * *", "0,30 */15 8-20/3 * * *"},
+		{"起始值间隔", "0 5/20 2/8 * * *", "0 5/20 2/8 * * *"},
+		{"列表", "0 5,15 5 * * *", "0 5,15 5 * * *"},
+		{"名称", "0 0 9 * JAN,JUL MON-FRI", "0 0 9 * 1,7 1-5"},
+		{"跨越周期", "0 0 22-2 * * *", "0 0 0-2,22,23 * * *"},
+		{"周日", "0 0 0 * * 7", "0 0 0 * * 0"},
+		{"年", "0 0 0 1 1 * 2027-2029", "0 0 0 1 1 * 2027-2029"},
+		{"月末", "0 0 18 L * ?", "0 0 18 L * *"},
+		{"月末前和工作日", "0 0 0 1,L-2,LW,15W * *", "0 0 0 1,L-2,LW,15W * *"},
+		{"第几个星期", "0 0 9 ? * 2#2,FRI#5", "0 0 9 * * 2#2,5#5"},
+		{"最后一个星期", "0 0 0 * * 1,5L", "0 0 0 * * 1,5L"},
+		{"时区", "TZ=Asia/Shanghai 0 0 9 * * *", "CRON_TZ=Asia/Shanghai 0 0 9 * * *"},
+		{"日期或星期", "CRON_DAY_OR=1 0 0 0 1,15 * 1", "CRON_DAY_OR=1 0 0 0 1,15 * 1"},
+		{"描述符", "@daily", "0 0 0 * * *"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := s.(*TimeSchedule).String()
+			if get != p.want {
+				t.Fatalf("want: %s, get: %s", p.want, get)
+			}
+
+			// 规范表达式解析后应得到相同的调度器
+			s2, err := Parse(get)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts, ts2 := *s.(*TimeSchedule), *s2.(*TimeSchedule)
+			if ts.loc.String() != ts2.loc.String() {
+				t.Errorf("loc want: %s, get: %s", ts.loc, ts2.loc)
+			}
+			ts.loc, ts2.loc = nil, nil
+			if ts != ts2 {
+				t.Errorf("want: %+v, get: %+v", ts, ts2)
+			}
+		})
+	}
+}
+
+func Test_ParseDayOrPrefix(t *testing.T) {
+	s, err := NewParser(WithSeconds(), WithDayOr()).Parse("CRON_DAY_OR=0 0 0 0 1,15 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.(*TimeSchedule).dayOr {
+		t.Error("CRON_DAY_OR=0 应覆盖 WithDayOr")
+	}
+
+	_, err = Parse("CRON_DAY_OR=yes 0 0 0 1,15 * 1")
+	if pe, ok := err.(*ParseError); !ok || pe.Token != "CRON_DAY_OR=yes" {
+		t.Errorf("err: %v", err)
+	}
+}
+
+func Test_TimeScheduleMarshalEmpty(t *testing.T) {
+	s, err := NewParser(WithSeconds(), WithLenient()).Parse("0 0 0 32 * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.(*TimeSchedule).MarshalText(); err != errEmptyField {
+		t.Errorf("err: %v", err)
+	}
+}
+
+// 时区前缀可以被 Parse 解析时才能序列化
+func Test_TimeScheduleMarshalZone(t *testing.T) {
+	s, err := ParseInLocation("0 0 9 * * *", time.FixedZone("UTC+8", 8*3600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := s.(*TimeSchedule).MarshalText(); err == nil {
+		t.Errorf("固定偏移的时区应返回错误 get: %s", b)
+	}
+
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	if s, err = ParseInLocation("0 0 9 * * *", loc); err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.(*TimeSchedule).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var get TimeSchedule
+	if err := get.UnmarshalText(b); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	if get.String() != string(b) || !get.Next(from).Equal(s.Next(from)) {
+		t.Errorf("want: %s, get: %s", b, get.String())
+	}
+}
+
+func Test_OtherScheduleString(t *testing.T) {
+	start := time.Date(2019, 5, 20, 9, 0, 0, 0, time.FixedZone("", 8*3600))
+	data := []struct {
+		name string
+		s    Scheduler
+		want string
+	}{
+		{"间隔", &DurationSchedule{start: start, frequency: 90 * time.Minute}, "@every 1h30m0s from 2019-05-20T09:00:00+08:00"},
+		{"固定时间", &FixSchedule{rTime: start}, "@at 2019-05-20T09:00:00+08:00"},
+		{"纳秒", &FixSchedule{rTime: start.Add(time.Millisecond).UTC()}, "@at 2019-05-20T01:00:00.001Z"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			get := p.s.(interface{ String() string }).String()
+			if get != p.want {
+				t.Fatalf("want: %s, get: %s", p.want, get)
+			}
+
+			s, err := Parse(get)
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := start.Add(-time.Hour)
+			for i := 0; i < 3; i++ {
+				want, next := p.s.Next(now), s.Next(now)
+				if !want.Equal(next) {
+					t.Fatalf("want: %s, get: %s", want, next)
+				}
+				now = next
+			}
+		})
+	}
+}
+
+func Test_MarshalJSON(t *testing.T) {
+	type config struct {
+		Time     *TimeSchedule     `json:"time"`
+		Duration *DurationSchedule `json:"duration"`
+		Fix      *FixSchedule      `json:"fix"`
+		Any      Expr              `json:"any"`
+		Empty    Expr              `json:"empty"`
+	}
+
+	start := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	ts, err := Parse("0 0 9 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := Parse("CRON_TZ=UTC 0 0 0 L * ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config{
+		Time:     ts.(*TimeSchedule),
+		Duration: &DurationSchedule{start: start, frequency: time.Hour},
+		Fix:      &FixSchedule{rTime: start},
+		Any:      Expr{expr},
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"0 0 9 * * 1-5","duration":"@every 1h0m0s from 2019-05-20T00:00:00Z","fix":"@at 2019-05-20T00:00:00Z","any":"CRON_TZ=UTC 0 0 0 L * *","empty":""}`
+	if string(b) != want {
+		t.Fatalf("want: %s, get: %s", want, b)
+	}
+
+	var get config
+	if err := json.Unmarshal(b, &get); err != nil {
+		t.Fatal(err)
+	}
+	if get.Time.String() != c.Time.String() || get.Duration.String() != c.Duration.String() ||
+		get.Fix.String() != c.Fix.String() || get.Any.Scheduler.(*TimeSchedule).String() != expr.(*TimeSchedule).String() {
+		t.Errorf("want: %+v, get: %+v", c, get)
+	}
+	if get.Empty.Scheduler != nil {
+		t.Errorf("empty: %v", get.Empty.Scheduler)
+	}
+
+	if err := json.Unmarshal([]byte(`{"time":"@at 2019-05-20T00:00:00Z"}`), &get); err == nil {
+		t.Error("类型不匹配时应返回错误")
+	}
+	if err := json.Unmarshal([]byte(`{"any":"0 0 25 * * *"}`), &get); err == nil {
+		t.Error("无效表达式应返回错误")
+	}
+}
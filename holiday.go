@@ -0,0 +1,374 @@
+package corn
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar 需要排除的日期，如节假日
+type Calendar interface {
+	// IsExcluded date 所在的日期(按 date 的时区计算)是否被排除
+	IsExcluded(date time.Time) bool
+}
+
+// CalendarFunc 将函数转换为 Calendar
+type CalendarFunc func(date time.Time) bool
+
+// IsExcluded 实现 Calendar
+func (f CalendarFunc) IsExcluded(date time.Time) bool {
+	return f(date)
+}
+
+// Weekends 排除周六和周日
+var Weekends Calendar = CalendarFunc(func(date time.Time) bool {
+	w := date.Weekday()
+	return w == time.Saturday || w == time.Sunday
+})
+
+// Calendars 合并多个 Calendar，任一 Calendar 排除的日期都被排除，如排除周末和节假日:
+//  corn.Calendars(corn.Weekends, holidays)
+func Calendars(cals ...Calendar) Calendar {
+	return CalendarFunc(func(date time.Time) bool {
+		for _, c := range cals {
+			if c.IsExcluded(date) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HolidayCalendar 保存在内存中的节假日，可以并发使用
+type HolidayCalendar struct {
+	mu sync.RWMutex
+
+	// 排除的日期，值为距离 1970-01-01 的天数
+	days map[int]bool
+
+	// iCalendar 中重复的事件
+	rules []holidayRule
+}
+
+// holidayRule 重复的节假日，每次持续 days 天
+type holidayRule struct {
+	r    *RRuleSchedule
+	days int
+}
+
+// NewHolidayCalendar 排除 dates 所在日期的 Calendar
+func NewHolidayCalendar(dates ...time.Time) *HolidayCalendar {
+	h := &HolidayCalendar{days: make(map[int]bool)}
+	h.Add(dates...)
+	return h
+}
+
+// Add 添加排除的日期，按各时间自身的时区计算日期
+func (h *HolidayCalendar) Add(dates ...time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, d := range dates {
+		h.days[civilDay(d)] = true
+	}
+}
+
+// IsExcluded 实现 Calendar
+func (h *HolidayCalendar) IsExcluded(date time.Time) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.days[civilDay(date)] {
+		return true
+	}
+
+	// 重复的节假日在 [date-days+1, date] 内开始时排除 date
+	y, m, d := date.Date()
+	for _, rule := range h.rules {
+		loc := rule.r.dtstart.Location()
+		lo := time.Date(y, m, d-rule.days+1, 0, 0, 0, 0, loc)
+		hi := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		if n := rule.r.Next(lo.Add(-time.Nanosecond)); !n.IsZero() && n.Before(hi) {
+			return true
+		}
+	}
+	return false
+}
+
+// csvDateLayouts CSV 中日期支持的格式
+var csvDateLayouts = []string{"2006-01-02", "2006/01/02", "20060102"}
+
+// LoadCSVCalendar 从 CSV 中读取节假日，每行第一列为日期(2006-01-02、2006/01/02 或 20060102)，其它列被忽略
+// 第一行不是日期时视为表头，以 '#' 开头的行为注释，如:
+//  date,name
+//  2019-10-01,国庆节
+//  2019-10-02,国庆节
+func LoadCSVCalendar(r io.Reader) (*HolidayCalendar, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	h := NewHolidayCalendar()
+	for n := 0; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return h, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		text := strings.TrimSpace(record[0])
+		var date time.Time
+		for _, layout := range csvDateLayouts {
+			if date, err = time.Parse(layout, text); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			if n == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("cron: record %d: invalid date %q", n+1, text)
+		}
+		h.Add(date)
+	}
+}
+
+// LoadICSCalendar 从 iCalendar(.ics)中读取节假日，每个 VEVENT 从 DTSTART 所在日期起排除至 DTEND 之前
+// (没有 DTEND 时只排除一天)，包含 RRULE 时排除每次重复的日期，重复规则同时支持 EXDATE、RDATE
+func LoadICSCalendar(r io.Reader) (*HolidayCalendar, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	props, err := splitRRuleLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHolidayCalendar()
+	var event []rruleProp
+	inEvent := false
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			event, inEvent = nil, true
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if err := h.addEvent(event); err != nil {
+				return nil, err
+			}
+			inEvent = false
+		case inEvent:
+			event = append(event, p)
+		}
+	}
+	return h, nil
+}
+
+// addEvent 添加 VEVENT 中的节假日
+func (h *HolidayCalendar) addEvent(props []rruleProp) error {
+	var (
+		start, end time.Time
+		rule       []string
+		repeated   bool
+		err        error
+	)
+	for i := range props {
+		p := &props[i]
+		switch p.name {
+		case "DTSTART":
+			if start, err = p.time(p.value, time.Local); err != nil {
+				return fmt.Errorf("cron: invalid DTSTART %q", p.value)
+			}
+			rule = append(rule, p.line)
+		case "DTEND":
+			if end, err = p.time(p.value, time.Local); err != nil {
+				return fmt.Errorf("cron: invalid DTEND %q", p.value)
+			}
+		case "RRULE":
+			rule, repeated = append(rule, p.line), true
+		case "EXDATE", "RDATE":
+			rule = append(rule, p.line)
+		}
+	}
+	if start.IsZero() {
+		return fmt.Errorf("cron: VEVENT without DTSTART")
+	}
+
+	// 持续的天数，DTEND 为结束时间(不包含)
+	days := 1
+	if !end.IsZero() {
+		end = end.In(start.Location())
+		days = civilDay(end) - civilDay(start)
+		if hour, min, sec := end.Clock(); hour != 0 || min != 0 || sec != 0 {
+			days++
+		}
+		if days < 1 {
+			days = 1
+		}
+	}
+
+	if !repeated {
+		h.mu.Lock()
+		for i := 0; i < days; i++ {
+			h.days[civilDay(start.AddDate(0, 0, i))] = true
+		}
+		h.mu.Unlock()
+		return nil
+	}
+
+	r, err := ParseRRule(strings.Join(rule, "\n"))
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.rules = append(h.rules, holidayRule{r: r, days: days})
+	h.mu.Unlock()
+	return nil
+}
+
+var _ Calendar = new(HolidayCalendar)
+
+// HolidayPolicy 执行时间在排除的日期时的处理方式
+type HolidayPolicy int
+
+const (
+	// HolidaySkip 跳过这次执行
+	HolidaySkip HolidayPolicy = iota
+	// HolidayNext 顺延到下一个没有被排除的日期的相同时间
+	HolidayNext
+	// HolidayPrev 提前到上一个没有被排除的日期的相同时间
+	HolidayPrev
+)
+
+// maxHolidayDays 顺延或提前时最多查找的天数，超过时跳过这次执行
+const maxHolidayDays = 366
+
+// BusinessDaySchedule 按 Calendar 排除日期的调度器
+type BusinessDaySchedule struct {
+	s      Scheduler
+	cal    Calendar
+	policy HolidayPolicy
+}
+
+// BusinessDays 按 policy 处理 s 中落在 cal 排除日期的执行时间，如每月 15 日结算，遇到周末和节假日顺延:
+//  s, _ := corn.Parse("CRON_TZ=Asia/Shanghai 0 0 9 15 * *")
+//  cal := corn.Calendars(corn.Weekends, holidays)
+//  corn.BusinessDays(s, cal, corn.HolidayNext)
+// 日期按调度器的时区(TimeSchedule 的 CRON_TZ、RRuleSchedule 的 DTSTART)计算，其它调度器按执行时间自身的时区
+// 顺延或提前后与其它执行时间相同时只执行一次
+func BusinessDays(s Scheduler, cal Calendar, policy HolidayPolicy) *BusinessDaySchedule {
+	return &BusinessDaySchedule{s: s, cal: cal, policy: policy}
+}
+
+// local 执行时间在计算日期使用的时区中的时间
+func (b *BusinessDaySchedule) local(t time.Time) time.Time {
+	switch v := b.s.(type) {
+	case *TimeSchedule:
+		return t.In(v.loc)
+	case *RRuleSchedule:
+		return t.In(v.dtstart.Location())
+	}
+	return t
+}
+
+// excluded t 所在日期是否被排除
+func (b *BusinessDaySchedule) excluded(t time.Time) bool {
+	return b.cal.IsExcluded(b.local(t))
+}
+
+// shift 将 t 移动到 step 方向上第一个没有被排除的日期的相同时间，找不到时返回零时
+func (b *BusinessDaySchedule) shift(t time.Time, step int) time.Time {
+	l := b.local(t)
+	y, m, d := l.Date()
+	hour, min, sec := l.Clock()
+	for i := 1; i <= maxHolidayDays; i++ {
+		if r := time.Date(y, m, d+i*step, hour, min, sec, l.Nanosecond(), l.Location()); !b.cal.IsExcluded(r) {
+			return r.In(t.Location())
+		}
+	}
+	return time.Time{}
+}
+
+// nextDay t 之后第一个没有被排除的日期的开始时间，最多查找 maxHolidayDays 天，找不到时返回之后一天的开始时间
+func (b *BusinessDaySchedule) nextDay(t time.Time) time.Time {
+	l := b.local(t)
+	y, m, d := l.Date()
+	var day time.Time
+	for i := 1; i <= maxHolidayDays+1; i++ {
+		if day = time.Date(y, m, d+i, 0, 0, 0, 0, l.Location()); !b.cal.IsExcluded(day) {
+			break
+		}
+	}
+	return day
+}
+
+// Next 晚于 t 的下一次执行时间，没有时返回零时
+func (b *BusinessDaySchedule) Next(t time.Time) time.Time {
+	limit := combineLimit(t, true)
+	from := t
+	if b.policy == HolidayNext {
+		// 之前连续排除的日期中的执行时间可能顺延到 t 之后
+		l := b.local(t)
+		day := time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, l.Location())
+		for i := 0; i < maxHolidayDays && b.cal.IsExcluded(day.AddDate(0, 0, -1)); i++ {
+			day = day.AddDate(0, 0, -1)
+		}
+		from = day.Add(-time.Nanosecond)
+	}
+
+	var res time.Time
+	for it := NewIterator(b.s, from); it.Next(); {
+		o := it.Time()
+		if o.After(limit) || !res.IsZero() && b.done(o, res) {
+			break
+		}
+
+		cand := o
+		if b.excluded(o) {
+			switch b.policy {
+			case HolidayNext:
+				cand = b.shift(o, 1)
+			case HolidayPrev:
+				cand = b.shift(o, -1)
+			default:
+				// 跳过排除的日期中剩余的执行时间
+				it = NewIterator(b.s, b.nextDay(o).Add(-time.Nanosecond))
+				continue
+			}
+		}
+		if !cand.IsZero() && cand.After(t) && (res.IsZero() || cand.Before(res)) {
+			res = cand
+		}
+		if !res.IsZero() && b.policy != HolidayPrev && !b.excluded(o) && o.After(t) {
+			break
+		}
+	}
+	return res
+}
+
+// done 原始执行时间 o 及之后的执行时间是否都不会早于 res
+func (b *BusinessDaySchedule) done(o, res time.Time) bool {
+	if b.policy != HolidayPrev {
+		// 顺延只会推迟执行时间
+		return !o.Before(res)
+	}
+	// 提前最多到 o 之前最近的没有被排除的日期，该日期晚于 res 所在日期时不会更早
+	lo, lr := b.local(o), b.local(res)
+	return !b.excluded(o) && civilDay(lo) > civilDay(lr)
+}
+
+// Last 最后一次执行时间，s 没有限制时返回零时
+func (b *BusinessDaySchedule) Last() time.Time {
+	last := b.s.Last()
+	if last.IsZero() {
+		return time.Time{}
+	}
+	return prev(b, last.AddDate(0, 0, maxHolidayDays+1))
+}
+
+var _ Scheduler = new(BusinessDaySchedule)
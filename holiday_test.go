@@ -0,0 +1,203 @@
+package corn
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_HolidayCalendar(t *testing.T) {
+	h := NewHolidayCalendar(time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC))
+	h.Add(time.Date(2019, 10, 2, 23, 0, 0, 0, time.FixedZone("", 8*3600)))
+	cal := Calendars(Weekends, h)
+
+	data := []struct {
+		date     time.Time
+		excluded bool
+	}{
+		{time.Date(2019, 10, 1, 12, 0, 0, 0, time.Local), true},
+		{time.Date(2019, 10, 2, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2019, 10, 5, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2019, 10, 7, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, p := range data {
+		if get := cal.IsExcluded(p.date); get != p.excluded {
+			t.Errorf("%s want: %v, get: %v", p.date.Format("2006-01-02"), p.excluded, get)
+		}
+	}
+}
+
+func Test_LoadCSVCalendar(t *testing.T) {
+	h, err := LoadCSVCalendar(strings.NewReader("date,name\n# 国庆节\n2019-10-01,国庆节\n2019/10/02\n 20191003,\"国庆节\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for d := 1; d <= 4; d++ {
+		if get := h.IsExcluded(time.Date(2019, 10, d, 0, 0, 0, 0, time.UTC)); get != (d < 4) {
+			t.Errorf("10-%02d get: %v", d, get)
+		}
+	}
+
+	_, err = LoadCSVCalendar(strings.NewReader("2019-10-01\n2019-13-01\n"))
+	if err == nil || err.Error() != `cron: record 2: invalid date "2019-13-01"` {
+		t.Errorf("err: %v", err)
+	}
+}
+
+func Test_LoadICSCalendar(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"SUMMARY:国庆节",
+		"DTSTART;VALUE=DATE:20191001",
+		"DTEND;VALUE=DATE:20191008",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Christmas",
+		"DTSTART;VALUE=DATE:20181225",
+		"RRULE:FREQ=YEARLY",
+		"EXDATE;VALUE=DATE:20201225",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Maintenance",
+		"DTSTART;TZID=Asia/Shanghai:20191110T220000",
+		"DTEND;TZID=Asia/Shanghai:20191111T020000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	h, err := LoadICSCalendar(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		date     string
+		excluded bool
+	}{
+		{"2019-09-30", false},
+		{"2019-10-01", true},
+		{"2019-10-07", true},
+		{"2019-10-08", false},
+		{"2019-12-25", true},
+		{"2019-12-26", false},
+		{"2020-12-25", false},
+		{"2021-12-25", true},
+		{"2019-11-10", true},
+		{"2019-11-11", true},
+		{"2019-11-12", false},
+	}
+	for _, p := range data {
+		date, _ := time.ParseInLocation("2006-01-02", p.date, time.Local)
+		if get := h.IsExcluded(date); get != p.excluded {
+			t.Errorf("%s want: %v, get: %v", p.date, p.excluded, get)
+		}
+	}
+
+	if _, err := LoadICSCalendar(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n")); err == nil {
+		t.Error("没有 DTSTART 时应返回错误")
+	}
+}
+
+func Test_BusinessDays(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	d := func(m time.Month, day int) time.Time {
+		return time.Date(2019, m, day, 9, 0, 0, 0, shanghai)
+	}
+	cal := Calendars(Weekends, NewHolidayCalendar(time.Date(2019, 10, 15, 0, 0, 0, 0, shanghai)))
+	monthly, err := Parse("CRON_TZ=Asia/Shanghai 0 0 9 15 * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily, err := Parse("CRON_TZ=Asia/Shanghai 0 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		name   string
+		s      Scheduler
+		policy HolidayPolicy
+		from   time.Time
+		want   []time.Time
+	}{
+		{"跳过", monthly, HolidaySkip, d(6, 1), []time.Time{d(7, 15), d(8, 15), d(11, 15), d(1, 15).AddDate(1, 0, 0)}},
+		{"顺延", monthly, HolidayNext, d(6, 1), []time.Time{d(6, 17), d(7, 15), d(8, 15), d(9, 16), d(10, 16), d(11, 15)}},
+		{"提前", monthly, HolidayPrev, d(6, 1), []time.Time{d(6, 14), d(7, 15), d(8, 15), d(9, 13), d(10, 14), d(11, 15)}},
+		{"在排除的日期开始顺延", monthly, HolidayNext, d(6, 16), []time.Time{d(6, 17), d(7, 15)}},
+		{"顺延后相同的时间只执行一次", daily, HolidayNext, d(5, 17), []time.Time{d(5, 20), d(5, 21)}},
+		{"提前后相同的时间只执行一次", daily, HolidayPrev, d(5, 16), []time.Time{d(5, 17), d(5, 20)}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			b := BusinessDays(p.s, cal, p.policy)
+			get := NextN(b, p.from, len(p.want))
+			if len(get) != len(p.want) {
+				t.Fatalf("want: %v, get: %v", p.want, get)
+			}
+			for i := range get {
+				if !get[i].Equal(p.want[i]) {
+					t.Errorf("第 %d 次 want: %s, get: %s", i, p.want[i], get[i])
+				}
+			}
+			for i := len(get) - 1; i > 0; i-- {
+				if prev := Prev(b, get[i]); !prev.Equal(get[i-1]) {
+					t.Errorf("Prev(%s) want: %s, get: %s", get[i], get[i-1], prev)
+				}
+			}
+		})
+	}
+}
+
+// 跳过时直接跳到下一个没有被排除的日期，不逐个检查排除的日期中的执行时间
+func Test_BusinessDaysSkipDense(t *testing.T) {
+	every, err := Parse("CRON_TZ=UTC * * * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHolidayCalendar()
+	for d := 1; d <= 60; d++ {
+		h.Add(time.Date(2019, 1, d, 0, 0, 0, 0, time.UTC))
+	}
+
+	start := time.Now()
+	if get, want := BusinessDays(every, h, HolidaySkip).Next(from), time.Date(2019, 3, 2, 0, 0, 0, 0, time.UTC); !get.Equal(want) {
+		t.Errorf("want: %s, get: %s", want, get)
+	}
+	all := CalendarFunc(func(time.Time) bool { return true })
+	if get := BusinessDays(every, all, HolidaySkip).Next(from); !get.IsZero() {
+		t.Errorf("全部排除 get: %s", get)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("耗时: %s", d)
+	}
+}
+
+func Test_BusinessDaysLast(t *testing.T) {
+	// 2019-05-17 是周五，最后两次在周末
+	start := time.Date(2019, 5, 17, 9, 0, 0, 0, time.UTC)
+	ds := &DurationSchedule{start: start, frequency: 24 * time.Hour, count: 3}
+
+	data := []struct {
+		policy HolidayPolicy
+		want   time.Time
+	}{
+		{HolidaySkip, start},
+		{HolidayNext, start.AddDate(0, 0, 3)},
+		{HolidayPrev, start},
+	}
+	for _, p := range data {
+		if get := BusinessDays(ds, Weekends, p.policy).Last(); !get.Equal(p.want) {
+			t.Errorf("policy %d want: %s, get: %s", p.policy, p.want, get)
+		}
+	}
+	if get := BusinessDays(&DurationSchedule{start: start, frequency: time.Hour}, Weekends, HolidayNext).Last(); !get.IsZero() {
+		t.Errorf("没有限制 get: %s", get)
+	}
+}
@@ -0,0 +1,392 @@
+package corn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxICSOccurrences 无法用 RRULE 表示的调度器最多导出的执行次数
+const maxICSOccurrences = 1000
+
+// ICSOption 导出 iCalendar 的选项
+type ICSOption func(e *icsExporter)
+
+// WithICSLang 指定事件标题(SUMMARY)使用的语言，默认为中文
+func WithICSLang(lang Lang) ICSOption {
+	return func(e *icsExporter) {
+		e.lang = lang
+	}
+}
+
+// icsExporter 导出 iCalendar 的配置
+type icsExporter struct {
+	lang    Lang
+	from    time.Time
+	to      time.Time
+	dtstamp time.Time
+
+	// 使用 TZID 的时区，按第一次使用的顺序排列
+	zones []*icsZone
+}
+
+// icsZone 需要生成 VTIMEZONE 的时区
+type icsZone struct {
+	id  string
+	loc *time.Location
+
+	// 使用该时区的最早时间
+	start time.Time
+}
+
+// ExportICS 将 c 中的任务导出为 RFC 5545 iCalendar(.ics)文档，每个任务对应一个 VEVENT:
+//  TimeSchedule      日期不使用 W、LW、日期或星期(CRON_DAY_OR)、没有限制年份且使用默认的夏令时策略时导出为 RRULE
+//  DurationSchedule  间隔为整数秒时导出为 RRULE(按 UTC 时间)，有执行次数限制时包含 COUNT
+//  FixSchedule       导出为单次事件
+//  RRuleSchedule     直接导出 DTSTART、RRULE、EXDATE、RDATE
+// 其它调度器导出 [from, to) 内的执行时间(RDATE)，最多 1000 次
+// RRULE 的 DTSTART 为 from 之后第一次执行时间，from 之后不再执行的任务不会导出
+// UTC 以外的时区(包括 time.Local)使用 TZID 参数，并生成包含 from 至 to 之间时区切换的 VTIMEZONE
+// c 需要实现 Lister(如 NewCorn 返回的 Cron)，否则返回错误
+func ExportICS(w io.Writer, c Corner, from, to time.Time, opts ...ICSOption) error {
+	l, ok := c.(Lister)
+	if !ok {
+		return fmt.Errorf("cron: %T does not implement Lister", c)
+	}
+
+	e := &icsExporter{lang: LangZh, from: from, to: to, dtstamp: time.Now()}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	var events []string
+	for _, entry := range l.Entries() {
+		events = append(events, e.event(entry)...)
+	}
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Quieting//corn//EN", "CALSCALE:GREGORIAN"}
+	for _, z := range e.zones {
+		lines = append(lines, e.vtimezone(z)...)
+	}
+	lines = append(lines, events...)
+	lines = append(lines, "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// event 任务对应的 VEVENT，没有执行时间时返回 nil
+func (e *icsExporter) event(entry Entry) []string {
+	props := e.recurrence(entry.Scheduler)
+	if len(props) == 0 {
+		return nil
+	}
+
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + entry.ID + "@corn",
+		"DTSTAMP:" + e.dtstamp.UTC().Format("20060102T150405Z"),
+	}
+	lines = append(lines, props...)
+	lines = append(lines, "SUMMARY:"+escapeICSText(Describe(entry.Scheduler, e.lang)), "END:VEVENT")
+	return lines
+}
+
+// recurrence 调度器对应的 DTSTART、RRULE、EXDATE、RDATE
+func (e *icsExporter) recurrence(s Scheduler) []string {
+	switch v := s.(type) {
+	case *RRuleSchedule:
+		if e.first(v).IsZero() {
+			return nil
+		}
+		return e.rrule(v)
+	case *FixSchedule:
+		if v.rTime.Before(e.from) {
+			return nil
+		}
+		return []string{e.prop("DTSTART", v.rTime.Location(), v.rTime)}
+	case *TimeSchedule:
+		if r := v.rrule(e.first(v)); r != nil {
+			return e.rrule(r)
+		}
+	case *DurationSchedule:
+		if r := v.rrule(e.first(v)); r != nil {
+			return e.rrule(r)
+		}
+	}
+
+	// 无法用 RRULE 表示时列出执行时间
+	ts := e.occurrences(s)
+	if len(ts) == 0 {
+		return nil
+	}
+	props := []string{icalProp("DTSTART", time.UTC, ts[0])}
+	if len(ts) > 1 {
+		props = append(props, icalProp("RDATE", time.UTC, ts[1:]...))
+	}
+	return props
+}
+
+// rrule 重复规则对应的 DTSTART、RRULE、EXDATE、RDATE，UNTIL 总是使用 UTC 时间
+func (e *icsExporter) rrule(r *RRuleSchedule) []string {
+	loc := r.dtstart.Location()
+	lines := []string{e.prop("DTSTART", loc, r.dtstart), "RRULE:" + r.rule(true)}
+	if len(r.exdates) > 0 {
+		lines = append(lines, e.prop("EXDATE", loc, r.exdates...))
+	}
+	if len(r.rdates) > 0 {
+		lines = append(lines, e.prop("RDATE", loc, r.rdates...))
+	}
+	return lines
+}
+
+// prop 格式化包含时间的内容行，loc 不是 UTC 时使用 TZID 参数并记录需要生成 VTIMEZONE 的时区
+func (e *icsExporter) prop(name string, loc *time.Location, ts ...time.Time) string {
+	if loc == time.UTC {
+		return icalProp(name, loc, ts...)
+	}
+
+	id := icsTZID(loc)
+	var z *icsZone
+	for _, v := range e.zones {
+		if v.id == id {
+			z = v
+		}
+	}
+	if z == nil {
+		z = &icsZone{id: id, loc: loc, start: ts[0]}
+		e.zones = append(e.zones, z)
+	}
+	items := make([]string, len(ts))
+	for i, t := range ts {
+		if t.Before(z.start) {
+			z.start = t
+		}
+		items[i] = t.In(loc).Format("20060102T150405")
+	}
+	return name + ";TZID=" + id + ":" + strings.Join(items, ",")
+}
+
+// icsTZID 时区的 TZID，没有名称的固定时区使用偏移，如 UTC+0800
+func icsTZID(loc *time.Location) string {
+	if name := loc.String(); name != "" {
+		return name
+	}
+	_, offset := time.Now().In(loc).Zone()
+	return "UTC" + formatICSOffset(offset)
+}
+
+// icsScanStep 查找时区切换时的步长，小于各时区两次切换的间隔
+const icsScanStep = 24 * time.Hour
+
+// vtimezone 时区对应的 VTIMEZONE，包含从第一次使用到 to 之间的时区切换
+// 每次切换对应一个 STANDARD 或 DAYLIGHT(偏移大于其它切换时的偏移)
+func (e *icsExporter) vtimezone(z *icsZone) []string {
+	// observance 从 at 开始使用的偏移，from 为之前的偏移
+	type observance struct {
+		at       time.Time
+		name     string
+		from, to int
+	}
+
+	name, offset := z.start.In(z.loc).Zone()
+	obs := []observance{{at: z.start, name: name, from: offset, to: offset}}
+	min := offset
+	for t := z.start; t.Before(e.to); {
+		next := t.Add(icsScanStep)
+		if _, o := next.In(z.loc).Zone(); o == offset {
+			t = next
+			continue
+		}
+
+		// 二分查找切换的时刻(整秒)
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+			if _, o := mid.In(z.loc).Zone(); o == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		name, o := hi.In(z.loc).Zone()
+		obs = append(obs, observance{at: hi, name: name, from: offset, to: o})
+		if o < min {
+			min = o
+		}
+		offset, t = o, hi
+	}
+
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + z.id}
+	for _, ob := range obs {
+		kind := "STANDARD"
+		if len(obs) > 1 && ob.to > min {
+			kind = "DAYLIGHT"
+		}
+		lines = append(lines,
+			"BEGIN:"+kind,
+			// DTSTART 为切换前偏移的本地时间
+			"DTSTART:"+ob.at.UTC().Add(time.Duration(ob.from)*time.Second).Format("20060102T150405"),
+			"TZOFFSETFROM:"+formatICSOffset(ob.from),
+			"TZOFFSETTO:"+formatICSOffset(ob.to),
+		)
+		if ob.name != "" {
+			lines = append(lines, "TZNAME:"+escapeICSText(ob.name))
+		}
+		lines = append(lines, "END:"+kind)
+	}
+	return append(lines, "END:VTIMEZONE")
+}
+
+// formatICSOffset 格式化 UTC 偏移，如 +0800、-0430、+053028
+func formatICSOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		s += fmt.Sprintf("%02d", offset%60)
+	}
+	return s
+}
+
+// first 不早于 from 的第一次执行时间
+func (e *icsExporter) first(s Scheduler) time.Time {
+	if it := NewIterator(s, e.from.Add(-time.Nanosecond)); it.Next() {
+		return it.Time()
+	}
+	return time.Time{}
+}
+
+// occurrences [from, to) 内的执行时间，最多 maxICSOccurrences 次
+func (e *icsExporter) occurrences(s Scheduler) []time.Time {
+	var ts []time.Time
+	for it := NewIterator(s, e.from.Add(-time.Nanosecond)); len(ts) < maxICSOccurrences && it.Next() && it.Time().Before(e.to); {
+		ts = append(ts, it.Time())
+	}
+	return ts
+}
+
+// rrule 转换为从 dtstart 开始的重复规则，无法表示或 dtstart 为零时返回 nil
+// FREQ 取最细的没有限制的字段，其它有限制的字段使用 BYxxx 列出取值
+// 有第几个星期(n#k、nL)时 FREQ 只能是 MONTHLY 或 YEARLY
+func (t *TimeSchedule) rrule(dtstart time.Time) *RRuleSchedule {
+	if dtstart.IsZero() || t.dayOr || t.dst != (DSTPolicy{}) || t.year != RangeYear || t.nearestWeekday != 0 || t.lastWeekday {
+		return nil
+	}
+
+	r := &RRuleSchedule{dtstart: dtstart.In(t.loc), interval: 1, wkst: time.Monday}
+	ordinal := t.hasWeekDayRule()
+	hour, min, sec := t.timeFields()
+	clock := func(f fieldMask) []int {
+		if f.full() && !ordinal {
+			return nil
+		}
+		return maskInts(f)
+	}
+	r.bySecond, r.byMinute, r.byHour = clock(sec), clock(min), clock(hour)
+
+	days := fieldMask{t.day, 1, 31}
+	if !days.full() || t.hasDayRule() {
+		r.byMonthDay = maskInts(days)
+		for n := findBit(t.lastDay, 0, 30); n <= 30; n = findBit(t.lastDay, n+1, 30) {
+			r.byMonthDay = append(r.byMonthDay, -int(n)-1)
+		}
+	}
+	weekDays := fieldMask{t.weekDay, 0, 6}
+	if !weekDays.full() || t.hasWeekDayRule() {
+		for w := findBit(t.weekDay, 0, 6); w <= 6; w = findBit(t.weekDay, w+1, 6) {
+			r.byDay = append(r.byDay, rruleWeekDay{weekday: time.Weekday(w)})
+		}
+		for w, nth := range t.nthWeekDay {
+			for k := findBit(uint64(nth), 1, 5); k <= 5; k = findBit(uint64(nth), k+1, 5) {
+				r.byDay = append(r.byDay, rruleWeekDay{n: int(k), weekday: time.Weekday(w)})
+			}
+		}
+		for w := findBit(t.lastWeekDay, 0, 6); w <= 6; w = findBit(t.lastWeekDay, w+1, 6) {
+			r.byDay = append(r.byDay, rruleWeekDay{n: -1, weekday: time.Weekday(w)})
+		}
+	}
+	months := fieldMask{t.month, 1, 12}
+	if !months.full() {
+		r.byMonth = maskInts(months)
+	}
+
+	switch {
+	case !ordinal && sec.full():
+		r.freq = freqSecondly
+	case !ordinal && min.full():
+		r.freq = freqMinutely
+	case !ordinal && hour.full():
+		r.freq = freqHourly
+	case len(r.byMonthDay)+len(r.byDay) == 0:
+		r.freq = freqDaily
+	case len(r.byMonthDay) == 0 && !ordinal:
+		r.freq = freqWeekly
+	case months.full():
+		r.freq = freqMonthly
+	default:
+		r.freq = freqYearly
+	}
+	return r
+}
+
+// maskInts 字段的所有取值
+func maskInts(f fieldMask) []int {
+	var vs []int
+	for i := findBit(f.mask, f.min, f.max); i <= f.max; i = findBit(f.mask, i+1, f.max) {
+		vs = append(vs, int(i))
+	}
+	return vs
+}
+
+// rrule 转换为从 dtstart 开始的重复规则(UTC 时间)，间隔不是整数秒或 dtstart 为零时返回 nil
+func (d *DurationSchedule) rrule(dtstart time.Time) *RRuleSchedule {
+	if dtstart.IsZero() || d.frequency%time.Second != 0 {
+		return nil
+	}
+
+	r := &RRuleSchedule{dtstart: dtstart.UTC(), freq: freqSecondly, interval: int(d.frequency / time.Second), wkst: time.Monday}
+	if d.count > 0 {
+		// 从 dtstart 开始剩余的执行次数
+		r.count = d.count - int(dtstart.Sub(d.start)/d.frequency)
+	}
+	for _, u := range []struct {
+		freq rruleFreq
+		unit int
+	}{{freqDaily, 86400}, {freqHourly, 3600}, {freqMinutely, 60}} {
+		if r.interval%u.unit == 0 {
+			r.freq, r.interval = u.freq, r.interval/u.unit
+			break
+		}
+	}
+	return r
+}
+
+// escapeICSText 转义 TEXT 类型的值
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine 将超过 75 字节的内容行折叠为多行，不拆分 UTF-8 字符
+func foldICSLine(line string) string {
+	var b strings.Builder
+	for limit := 75; len(line) > limit; limit = 74 {
+		n := limit
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		b.WriteString(line[:n])
+		b.WriteString("\r\n ")
+		line = line[n:]
+	}
+	b.WriteString(line)
+	return b.String()
+}
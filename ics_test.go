@@ -0,0 +1,249 @@
+package corn
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_TimeScheduleRRule(t *testing.T) {
+	from := time.Date(2019, 5, 18, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		name string
+		expr string
+		rule string
+	}{
+		{"每秒", "* * * * * *", "FREQ=SECONDLY"},
+		{"每 15 分钟", "0 */15 * * * *", "FREQ=HOURLY;BYMINUTE=0,15,30,45;BYSECOND=0"},
+		{"每天", "0 30 9 * * *", "FREQ=DAILY;BYHOUR=9;BYMINUTE=30;BYSECOND=0"},
+		{"工作日", "0 0 9 * JAN,JUL MON-FRI", "FREQ=WEEKLY;BYMONTH=1,7;BYDAY=MO,TU,WE,TH,FR;BYHOUR=9;BYMINUTE=0;BYSECOND=0"},
+		{"月末", "0 0 18 L * ?", "FREQ=MONTHLY;BYMONTHDAY=-1;BYHOUR=18;BYMINUTE=0;BYSECOND=0"},
+		{"日期和星期", "0 0 0 1,L-2 * 1", "FREQ=MONTHLY;BYMONTHDAY=1,-3;BYDAY=MO;BYHOUR=0;BYMINUTE=0;BYSECOND=0"},
+		{"第几个星期", "0 0 * ? * 2#2,FRI#5", "FREQ=MONTHLY;BYDAY=2TU,5FR;BYHOUR=0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23;BYMINUTE=0;BYSECOND=0"},
+		{"最后一个星期", "0 0 0 * 3 5L", "FREQ=YEARLY;BYMONTH=3;BYDAY=-1FR;BYHOUR=0;BYMINUTE=0;BYSECOND=0"},
+		{"每年", "0 0 0 1 1 *", "FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=1;BYHOUR=0;BYMINUTE=0;BYSECOND=0"},
+		{"工作日规则", "0 0 0 15W * *", ""},
+		{"年", "0 0 0 1 1 * 2027", ""},
+		{"日期或星期", "CRON_DAY_OR=1 0 0 0 1 * 1", ""},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := Parse("CRON_TZ=Asia/Shanghai " + p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts := s.(*TimeSchedule)
+			r := ts.rrule(ts.Next(from))
+			if p.rule == "" {
+				if r != nil {
+					t.Fatalf("不应转换为 RRULE: %s", r.Rule())
+				}
+				return
+			}
+			if r == nil || r.Rule() != p.rule {
+				t.Fatalf("want: %s, get: %v", p.rule, r)
+			}
+
+			// 转换后的规则与原调度器执行时间相同
+			want, get := NextN(ts, from, 50), NextN(r, from, 50)
+			for i := range want {
+				if !want[i].Equal(get[i]) {
+					t.Fatalf("第 %d 次 want: %s, get: %s", i, want[i], get[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_DurationScheduleRRule(t *testing.T) {
+	start := time.Date(2019, 5, 18, 0, 0, 0, 0, time.FixedZone("", 8*3600))
+	data := []struct {
+		frequency time.Duration
+		rule      string
+	}{
+		{90 * time.Second, "FREQ=SECONDLY;INTERVAL=90"},
+		{90 * time.Minute, "FREQ=MINUTELY;INTERVAL=90"},
+		{time.Hour, "FREQ=HOURLY"},
+		{72 * time.Hour, "FREQ=DAILY;INTERVAL=3"},
+		{1500 * time.Millisecond, ""},
+	}
+
+	for _, p := range data {
+		d := &DurationSchedule{start: start, frequency: p.frequency}
+		r := d.rrule(start)
+		if p.rule == "" {
+			if r != nil {
+				t.Errorf("不应转换为 RRULE: %s", r.Rule())
+			}
+			continue
+		}
+		if r == nil || r.Rule() != p.rule || !r.dtstart.Equal(start) || r.dtstart.Location() != time.UTC {
+			t.Errorf("want: %s, get: %v", p.rule, r)
+		}
+	}
+}
+
+func Test_ExportICS(t *testing.T) {
+	from := time.Date(2019, 5, 18, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewCorn()
+	for _, expr := range []string{"CRON_TZ=Asia/Shanghai 0 0 9 * * 1-5", "CRON_TZ=UTC 0 0 0 15W * *"} {
+		if err := c.AddWithCorn(expr, func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Add(&DurationSchedule{start: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), frequency: 90 * time.Minute}, nil)
+	c.Add(&FixSchedule{time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}, nil)
+	c.Add(&FixSchedule{time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)}, nil)
+	r, err := ParseRRule("DTSTART;TZID=America/New_York:20190902T090000\nRRULE:FREQ=DAILY;COUNT=10\nEXDATE;TZID=America/New_York:20190903T090000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(r, nil)
+	r, err = ParseRRule("DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;COUNT=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(r, nil)
+
+	var b strings.Builder
+	if err := ExportICS(&b, c, from, to, WithICSLang(LangEn)); err != nil {
+		t.Fatal(err)
+	}
+	get := b.String()
+	for _, line := range strings.Split(strings.TrimSuffix(get, "\r\n"), "\r\n") {
+		if len(line) > 75 || strings.Contains(line, "\n") {
+			t.Errorf("无效的内容行: %q", line)
+		}
+	}
+
+	// 去掉随机的 UID 和 DTSTAMP 后比较
+	get = regexp.MustCompile(`(UID|DTSTAMP):[^\r]*\r\n`).ReplaceAllString(get, "")
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Quieting//corn//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Shanghai",
+		"BEGIN:STANDARD",
+		"DTSTART:20190520T090000",
+		"TZOFFSETFROM:+0800",
+		"TZOFFSETTO:+0800",
+		"TZNAME:CST",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VTIMEZONE",
+		"TZID:America/New_York",
+		"BEGIN:DAYLIGHT",
+		"DTSTART:20190902T090000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0400",
+		"TZNAME:EDT",
+		"END:DAYLIGHT",
+		"BEGIN:STANDARD",
+		"DTSTART:20191103T020000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0500",
+		"TZNAME:EST",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Asia/Shanghai:20190520T090000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;BYHOUR=9;BYMINUTE=0;BYSECOND=0",
+		"SUMMARY:At 09:00\\, only on Monday through Friday (Asia/Shanghai)",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20190614T000000Z",
+		"RDATE:20190715T000000Z,20190815T000000Z,20190916T000000Z,20191015T000000Z,2",
+		" 0191115T000000Z",
+		"SUMMARY:At 00:00\\, on the weekday nearest day 15 of the month (UTC)",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20190518T000000Z",
+		"RRULE:FREQ=MINUTELY;INTERVAL=90",
+		"SUMMARY:Every 1 hour 30 minutes\\, starting at 2019-05-01 00:00:00 UTC",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20190601T000000Z",
+		"SUMMARY:Once at 2019-06-01 00:00:00 UTC",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=America/New_York:20190902T090000",
+		"RRULE:FREQ=DAILY;COUNT=10",
+		"EXDATE;TZID=America/New_York:20190903T090000",
+		"SUMMARY:Repeats daily (FREQ=DAILY\\;COUNT=10)\\, starting at 2019-09-02 09:00",
+		" :00 EDT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if get != want {
+		t.Errorf("want:\n%s\nget:\n%s", want, get)
+	}
+}
+
+func Test_foldICSLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("每天", 20)
+	get := foldICSLine(line)
+	parts := strings.Split(get, "\r\n ")
+	for i, p := range parts {
+		if len(p) > 75 || i > 0 && len(p) > 74 {
+			t.Errorf("第 %d 行过长: %d", i, len(p))
+		}
+	}
+	if strings.Join(parts, "") != line {
+		t.Errorf("get: %q", get)
+	}
+	if foldICSLine("END:VEVENT") != "END:VEVENT" {
+		t.Error("短内容行不应折叠")
+	}
+}
+
+// 每个 TZID 都有对应的 VTIMEZONE，本地时间不导出为浮动时间
+func Test_ExportICSZones(t *testing.T) {
+	from := time.Date(2019, 5, 18, 0, 0, 0, 0, time.UTC)
+	c := NewCorn()
+	c.Add(&FixSchedule{time.Date(2019, 6, 1, 9, 0, 0, 0, time.Local)}, nil)
+	c.Add(&FixSchedule{time.Date(2019, 6, 1, 9, 0, 0, 0, time.FixedZone("", -(4*3600 + 30*60)))}, nil)
+	r, err := ParseRRule("DTSTART:20190601T090000\nRRULE:FREQ=DAILY;UNTIL=20190610T090000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(r, nil)
+
+	var b strings.Builder
+	if err := ExportICS(&b, c, from, from.AddDate(1, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	get := b.String()
+	local := icsTZID(time.Local)
+	for _, want := range []string{
+		"DTSTART;TZID=" + local + ":20190601T090000\r\n",
+		"DTSTART;TZID=UTC-0430:20190601T090000\r\n",
+		"TZID:" + local + "\r\n",
+		"TZID:UTC-0430\r\n",
+		"TZOFFSETTO:-0430\r\n",
+		"RRULE:FREQ=DAILY;UNTIL=" + time.Date(2019, 6, 10, 9, 0, 0, 0, time.Local).UTC().Format("20060102T150405Z") + "\r\n",
+	} {
+		if !strings.Contains(get, want) {
+			t.Errorf("缺少 %q:\n%s", want, get)
+		}
+	}
+	if strings.Count(get, "BEGIN:VTIMEZONE") != 2 {
+		t.Errorf("VTIMEZONE 数量错误:\n%s", get)
+	}
+}
+
+// noListCorner 没有实现 Lister 的 Corner
+type noListCorner struct{ Corner }
+
+func Test_ExportICSWithoutLister(t *testing.T) {
+	var b strings.Builder
+	if err := ExportICS(&b, noListCorner{defaultCorner()}, time.Now(), time.Now()); err == nil {
+		t.Error("没有实现 Lister 时应返回错误")
+	}
+}
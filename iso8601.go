@@ -0,0 +1,203 @@
+package corn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoTimeLayouts ISO 8601 时间支持的格式，没有时区时使用 time.Local
+var isoTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"20060102T150405Z0700",
+	"20060102T150405",
+}
+
+// ParseISO8601 解析 ISO 8601 重复时间间隔，格式如下:
+//  Rn/开始时间/时长      从开始时间起每隔时长执行，共 n 次，如 R5/2026-11-01T00:00:00Z/PT6H
+//  Rn/时长/结束时间      每隔时长执行 n 次，最后一个间隔在结束时间结束
+//  Rn/开始时间/结束时间  时长为两个时间之差
+//  Rn/时长               从解析时刻(精确到秒)起每隔时长执行，如 R/P1D
+// 省略 n(R/...)或 n 为 -1 表示没有次数限制，此时不能使用时长/结束时间的格式
+// 时长支持 W、D、H、M、S(秒可以有小数)，D 固定为 24 小时，不支持长度不固定的年(Y)和月(M)
+// 时间可以是 2026-11-01T00:00:00Z 或 20261101T000000Z，没有时区时使用 time.Local
+// 解析失败时返回 *ParseError
+func ParseISO8601(spec string) (*DurationSchedule, error) {
+	var parts []field
+	offset := len(spec) - len(strings.TrimLeft(spec, " \t"))
+	for i, text := range strings.Split(strings.TrimSpace(spec), "/") {
+		parts = append(parts, field{text: text, offset: offset, index: i})
+		offset += len(text) + 1
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: "expected Rn/start/duration, Rn/duration/end, Rn/start/end or Rn/duration"}
+	}
+
+	// 次数
+	repeat := parts[0]
+	if len(repeat.text) == 0 || repeat.text[0] != 'R' {
+		return nil, newParseError(spec, repeat, -1, "missing repeat count")
+	}
+	count := 0
+	if n := repeat.text[1:]; n != "" && n != "-1" {
+		v, err := strconv.Atoi(n)
+		if err != nil || v < 1 {
+			return nil, newParseError(spec, repeat, -1, "invalid repeat count")
+		}
+		count = v
+	}
+
+	d := &DurationSchedule{count: count}
+	if len(parts) == 2 {
+		frequency, err := parseISODuration(parts[1].text)
+		if err != nil {
+			return nil, newParseError(spec, parts[1], -1, err.Error())
+		}
+		d.start, d.frequency = time.Now().Truncate(time.Second), frequency
+		return d, nil
+	}
+
+	first, second := parts[1], parts[2]
+	switch {
+	case strings.HasPrefix(first.text, "P"):
+		frequency, err := parseISODuration(first.text)
+		if err != nil {
+			return nil, newParseError(spec, first, -1, err.Error())
+		}
+		end, err := parseISOTime(second.text)
+		if err != nil {
+			return nil, newParseError(spec, second, -1, "invalid end time")
+		}
+		if count == 0 {
+			return nil, newParseError(spec, repeat, -1, "unbounded repetition requires a start time")
+		}
+		d.start, d.frequency = end.Add(-time.Duration(count)*frequency), frequency
+	default:
+		start, err := parseISOTime(first.text)
+		if err != nil {
+			return nil, newParseError(spec, first, -1, "invalid start time")
+		}
+		d.start = start
+		if strings.HasPrefix(second.text, "P") {
+			if d.frequency, err = parseISODuration(second.text); err != nil {
+				return nil, newParseError(spec, second, -1, err.Error())
+			}
+			break
+		}
+		end, err := parseISOTime(second.text)
+		if err != nil {
+			return nil, newParseError(spec, second, -1, "invalid end time")
+		}
+		if d.frequency = end.Sub(start); d.frequency <= 0 {
+			return nil, newParseError(spec, second, -1, "end time must be after start time")
+		}
+	}
+	return d, nil
+}
+
+// parseISOTime 解析 ISO 8601 时间
+func parseISOTime(text string) (time.Time, error) {
+	var err error
+	for _, layout := range isoTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// 时长的解析错误
+var (
+	errDuration         = errors.New("invalid duration")
+	errDurationPositive = errors.New("duration must be positive")
+	errDurationUnit     = errors.New("years and months are not supported")
+)
+
+// isoDurationUnit 时长单位对应的时间，inTime 表示在 T 之后
+func isoDurationUnit(c byte, inTime bool) (time.Duration, error) {
+	switch {
+	case !inTime && c == 'W':
+		return 7 * 24 * time.Hour, nil
+	case !inTime && c == 'D':
+		return 24 * time.Hour, nil
+	case !inTime && (c == 'Y' || c == 'M'):
+		return 0, errDurationUnit
+	case inTime && c == 'H':
+		return time.Hour, nil
+	case inTime && c == 'M':
+		return time.Minute, nil
+	case inTime && c == 'S':
+		return time.Second, nil
+	}
+	return 0, errDuration
+}
+
+// parseISODuration 解析 ISO 8601 时长，如 P1D、PT6H、P1DT12H、PT0.5S
+func parseISODuration(text string) (time.Duration, error) {
+	if len(text) < 2 || text[0] != 'P' || strings.HasSuffix(text, "T") {
+		return 0, errDuration
+	}
+
+	var (
+		d      time.Duration
+		inTime bool
+		num    string
+	)
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c >= '0' && c <= '9' || c == '.' || c == ',':
+			num += string(c)
+		case c == 'T' && !inTime && num == "":
+			inTime = true
+		default:
+			unit, err := isoDurationUnit(c, inTime)
+			if err != nil {
+				return 0, err
+			}
+			v, err := strconv.ParseFloat(strings.Replace(num, ",", ".", 1), 64)
+			if err != nil {
+				return 0, errDuration
+			}
+			d += time.Duration(math.Round(v * float64(unit)))
+			num = ""
+		}
+	}
+	if num != "" {
+		return 0, errDuration
+	}
+	if d <= 0 {
+		return 0, errDurationPositive
+	}
+	return d, nil
+}
+
+// formatISODuration 格式化为 ISO 8601 时长，如 P1DT6H、PT0.5S
+func formatISODuration(d time.Duration) string {
+	s := "P"
+	if days := d / (24 * time.Hour); days > 0 {
+		s += fmt.Sprintf("%dD", days)
+		d -= days * 24 * time.Hour
+	}
+	if d == 0 {
+		return s
+	}
+	s += "T"
+	if h := d / time.Hour; h > 0 {
+		s += fmt.Sprintf("%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		s += fmt.Sprintf("%dM", m)
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		s += strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseISO8601(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		name      string
+		spec      string
+		start     time.Time
+		frequency time.Duration
+		count     int
+	}{
+		{"开始时间和时长", "R5/2026-11-01T00:00:00Z/PT6H", start, 6 * time.Hour, 5},
+		{"时长和结束时间", "R4/P1D/2026-11-05T00:00:00Z", start, 24 * time.Hour, 4},
+		{"开始和结束时间", "R3/2026-11-01T00:00:00Z/2026-11-01T01:30:00Z", start, 90 * time.Minute, 3},
+		{"没有次数限制", "R/2026-11-01T00:00:00Z/P1W", start, 7 * 24 * time.Hour, 0},
+		{"R-1", "R-1/2026-11-01T00:00:00Z/PT1M", start, time.Minute, 0},
+		{"基本格式", "R2/20261101T000000Z/P1DT12H1M", start, 36*time.Hour + time.Minute, 2},
+		{"小数秒", "R10/2026-11-01T00:00:00Z/PT0,5S", start, 500 * time.Millisecond, 10},
+		{"时区", "R1/2026-11-01T08:00:00+08:00/PT30M", start, 30 * time.Minute, 1},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			d, err := ParseISO8601(p.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.start.Equal(p.start) || d.frequency != p.frequency || d.count != p.count {
+				t.Errorf("want: %s %s %d, get: %s %s %d", p.start, p.frequency, p.count, d.start, d.frequency, d.count)
+			}
+		})
+	}
+
+	// 只有时长时从当前时间开始
+	before := time.Now().Truncate(time.Second)
+	d, err := ParseISO8601("R/P1D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.start.Before(before) || d.start.After(time.Now()) || d.frequency != 24*time.Hour || d.count != 0 {
+		t.Errorf("get: %+v", d)
+	}
+}
+
+func Test_ParseISO8601Error(t *testing.T) {
+	data := []struct {
+		name   string
+		spec   string
+		token  string
+		offset int
+		reason string
+	}{
+		{"缺少次数", "2026-11-01T00:00:00Z/PT6H", "2026-11-01T00:00:00Z", 0, "missing repeat count"},
+		{"无效次数", "R0/2026-11-01T00:00:00Z/PT6H", "R0", 0, "invalid repeat count"},
+		{"年", "R5/2026-11-01T00:00:00Z/P1Y", "P1Y", 24, "years and months are not supported"},
+		{"月", "R5/P1M/2026-11-01T00:00:00Z", "P1M", 3, "years and months are not supported"},
+		{"无效时长", "R5/2026-11-01T00:00:00Z/PT", "PT", 24, "invalid duration"},
+		{"时长为零", "R5/2026-11-01T00:00:00Z/PT0S", "PT0S", 24, "duration must be positive"},
+		{"无效开始时间", "R5/2026-11-01/PT6H", "2026-11-01", 3, "invalid start time"},
+		{"结束时间早于开始时间", "R5/2026-11-01T00:00:00Z/2026-10-01T00:00:00Z", "2026-10-01T00:00:00Z", 24, "end time must be after start time"},
+		{"没有开始时间", "R/PT6H/2026-11-01T00:00:00Z", "R", 0, "unbounded repetition requires a start time"},
+		{"格式", "R5", "", -1, "expected Rn/start/duration, Rn/duration/end, Rn/start/end or Rn/duration"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := ParseISO8601(p.spec)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("err: %v", err)
+			}
+			if pe.Token != p.token || pe.Offset != p.offset || pe.Reason != p.reason {
+				t.Errorf("token: %q, offset: %d, reason: %q", pe.Token, pe.Offset, pe.Reason)
+			}
+		})
+	}
+}
+
+func Test_DurationScheduleCount(t *testing.T) {
+	d, err := ParseISO8601("R3/2026-11-01T00:00:00Z/PT6H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	want := []time.Time{start, start.Add(6 * time.Hour), start.Add(12 * time.Hour)}
+	get := NextN(d, start.Add(-time.Second), 4)
+	if len(get) != len(want) {
+		t.Fatalf("want: %v, get: %v", want, get)
+	}
+	for i := range want {
+		if !get[i].Equal(want[i]) {
+			t.Errorf("want: %s, get: %s", want[i], get[i])
+		}
+	}
+	if last := d.Last(); !last.Equal(want[2]) {
+		t.Errorf("Last want: %s, get: %s", want[2], last)
+	}
+	if next := d.Next(start.Add(time.Hour * 24)); !next.IsZero() {
+		t.Errorf("最后一次之后 want: 零时, get: %s", next)
+	}
+
+	// 序列化为 ISO 8601 格式
+	if s := d.String(); s != "R3/2026-11-01T00:00:00Z/PT6H" {
+		t.Errorf("String: %s", s)
+	}
+	var e Expr
+	if err := e.UnmarshalText([]byte(d.String())); err != nil {
+		t.Fatal(err)
+	}
+	if ds, ok := e.Scheduler.(*DurationSchedule); !ok || *ds != *d {
+		t.Errorf("want: %+v, get: %+v", d, e.Scheduler)
+	}
+
+	if get := Describe(d, LangEn); get != "Every 6 hours, 3 times, starting at 2026-11-01 00:00:00 UTC" {
+		t.Errorf("get: %s", get)
+	}
+	if get := Describe(d, LangZh); get != "从 2026-11-01 00:00:00 UTC 开始每隔 6 小时，共 3 次" {
+		t.Errorf("get: %s", get)
+	}
+
+	// 导出 iCalendar 时 COUNT 为剩余次数
+	if r := d.rrule(want[1]); r == nil || r.Rule() != "FREQ=HOURLY;INTERVAL=6;COUNT=2" {
+		t.Errorf("get: %v", r)
+	}
+}
+
+func Test_formatISODuration(t *testing.T) {
+	data := []struct {
+		d    time.Duration
+		want string
+	}{
+		{6 * time.Hour, "PT6H"},
+		{24 * time.Hour, "P1D"},
+		{36*time.Hour + 90*time.Second, "P1DT12H1M30S"},
+		{1500 * time.Millisecond, "PT1.5S"},
+	}
+	for _, p := range data {
+		get := formatISODuration(p.d)
+		if get != p.want {
+			t.Errorf("want: %s, get: %s", p.want, get)
+		}
+		if d, err := parseISODuration(get); err != nil || d != p.d {
+			t.Errorf("want: %s, get: %s %v", p.d, d, err)
+		}
+	}
+}
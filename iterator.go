@@ -0,0 +1,58 @@
+package corn
+
+import "time"
+
+// Iterator 按时间顺序遍历调度器的执行时间，用法如下:
+//  it := corn.NewIterator(s, time.Now())
+//  for it.Next() {
+//  	fmt.Println(it.Time())
+//  }
+// 调度器返回零时、没有晚于上一次的时间或超过 Last() 时结束
+type Iterator struct {
+	s    Scheduler
+	cur  time.Time
+	last time.Time
+	done bool
+}
+
+// NewIterator 遍历调度器晚于 from 的执行时间
+func NewIterator(s Scheduler, from time.Time) *Iterator {
+	return &Iterator{s: s, cur: from, last: s.Last()}
+}
+
+// Next 查找下一次执行时间，没有时返回 false，之后一直返回 false
+func (it *Iterator) Next() bool {
+	if it.done {
+		return false
+	}
+	next := it.s.Next(it.cur)
+	if next.IsZero() || !next.After(it.cur) || !it.last.IsZero() && next.After(it.last) {
+		it.done = true
+		return false
+	}
+	it.cur = next
+	return true
+}
+
+// Time 当前的执行时间，Next 返回 true 之后有效
+func (it *Iterator) Time() time.Time {
+	return it.cur
+}
+
+// NextN 调度器晚于 from 的前 n 次执行时间，不足 n 次时返回全部
+func NextN(s Scheduler, from time.Time, n int) []time.Time {
+	var ts []time.Time
+	for it := NewIterator(s, from); len(ts) < n && it.Next(); {
+		ts = append(ts, it.Time())
+	}
+	return ts
+}
+
+// Between 调度器在 [start, end) 内的全部执行时间
+func Between(s Scheduler, start, end time.Time) []time.Time {
+	var ts []time.Time
+	for it := NewIterator(s, start.Add(-time.Nanosecond)); it.Next() && it.Time().Before(end); {
+		ts = append(ts, it.Time())
+	}
+	return ts
+}
@@ -0,0 +1,71 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NextN(t *testing.T) {
+	from := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	ts, err := Parse("CRON_TZ=UTC 0 0 8 * * * 2019")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		name string
+		s    Scheduler
+		from time.Time
+		n    int
+		want []time.Time
+	}{
+		{"每天", ts, from, 3, []time.Time{from.Add(8 * time.Hour), from.Add(32 * time.Hour), from.Add(56 * time.Hour)}},
+		{"超过最后一次", ts, time.Date(2019, 12, 30, 12, 0, 0, 0, time.UTC), 3, []time.Time{time.Date(2019, 12, 31, 8, 0, 0, 0, time.UTC)}},
+		{"执行次数", &DurationSchedule{start: from, frequency: time.Hour, count: 2}, from.Add(-time.Second), 5, []time.Time{from, from.Add(time.Hour)}},
+		{"固定时间", &FixSchedule{from}, from.Add(-time.Second), 5, []time.Time{from}},
+		{"固定时间之后", &FixSchedule{from}, from, 5, nil},
+		{"零次", ts, from, 0, nil},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			get := NextN(p.s, p.from, p.n)
+			if len(get) != len(p.want) {
+				t.Fatalf("want: %v, get: %v", p.want, get)
+			}
+			for i := range get {
+				if !get[i].Equal(p.want[i]) {
+					t.Errorf("want: %s, get: %s", p.want[i], get[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_Between(t *testing.T) {
+	start := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	ds := &DurationSchedule{start: start, frequency: 15 * time.Minute}
+
+	// 包含开始时间，不包含结束时间
+	get := Between(ds, start, start.Add(time.Hour))
+	if len(get) != 4 || !get[0].Equal(start) || !get[3].Equal(start.Add(45*time.Minute)) {
+		t.Errorf("get: %v", get)
+	}
+	if get := Between(ds, start.Add(time.Hour), start); len(get) != 0 {
+		t.Errorf("结束时间早于开始时间 get: %v", get)
+	}
+}
+
+func Test_Iterator(t *testing.T) {
+	start := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	it := NewIterator(&DurationSchedule{start: start, frequency: time.Hour, count: 2}, start)
+	if !it.Next() || !it.Time().Equal(start.Add(time.Hour)) {
+		t.Fatalf("get: %s", it.Time())
+	}
+	// 结束之后一直返回 false
+	for i := 0; i < 3; i++ {
+		if it.Next() {
+			t.Fatalf("第 %d 次 get: %s", i, it.Time())
+		}
+	}
+}
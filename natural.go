@@ -0,0 +1,1030 @@
+package corn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseNatural 解析描述执行时间的英文或中文短语(包含汉字时按中文解析)，返回按 time.Local 计算的调度器
+//
+// 英文短语由以下子句组成(不区分大小写，子句之间可以用 ',' 或 and 分割):
+//  every N seconds|minutes|hours         每隔 N 秒、分钟、小时，N 为 1 时可以省略，如 every 15 minutes、every hour
+//                                        N 不能整除 60(小时为 24)时从解析时刻起每隔 N 执行，返回 *DurationSchedule，
+//                                        此时不能再指定日期、星期、月份或时间范围
+//  every day|weekday|weekend             每天、工作日、周末，也可以写成 daily、weekdays、weekends
+//  every <星期>                          如 every monday、every mon, wed and fri、every monday-friday
+//  every week|month|year                 每周日、每月 1 日、每年 1 月 1 日，也可以写成 weekly、monthly、yearly
+//  on <星期>|weekdays|weekends           同 every
+//  on the <日期>[ of the month]          如 on the 1st and 15th、on the last day
+//  on <月份> <日期>                      如 on jan 1st、on december 25
+//  in <月份>                             如 in january and july
+//  at <时间>                             如 at 9:30、at 9am and 6pm、at noon、at midnight
+//  between|from <时间> and|to <时间>     间隔执行的时间范围
+// 举例如下:
+//  every weekday at 9:30                 工作日 9:30 执行
+//  every 15 minutes between 9:00 and 17:00   9:00 至 16:45 每 15 分钟执行
+//  every month on the last day at 6pm    每月最后一天 18:00 执行
+//
+// 中文短语由以下部分组成(可以用空格、'，'、'、'、和 分割):
+//  每[隔]N秒|分钟|小时                   每隔 N 秒、分钟、小时，如 每15分钟、每隔2小时、每小时，N 的限制与英文相同
+//  每天|每日|天天                        每天
+//  [每个]工作日|周末                     周一至周五、周六和周日
+//  每周|周|星期<星期>                    如 每周一、每周一、三、五、周一到周五、星期日
+//  每月<日期>                            如 每月1号、每月1日和15日、每月最后一天
+//  每年<月>月<日>日|号                   如 每年1月1日
+//  [时段]<时>点|时[<分>分|半|一刻|三刻]  如 早上8点、下午3点半、晚上8点15分、9:30，时段为 凌晨、早上、上午、中午、下午、傍晚、晚上
+//  <时间>到|至<时间>[之间]               间隔执行的时间范围
+// 数字可以是阿拉伯数字或中文数字(如 十五、二十三)
+//
+// 没有指定时间时在 0 点执行；间隔执行的时间范围包含开始时间不包含结束时间(间隔为小时时包含结束时间)
+// 多个时间需要能组合成一个表达式，如 8:30 和 18:30 可以，8:30 和 18:00 不可以
+// 解析失败时返回 *ParseError，Token 和 Offset 为无法识别的部分
+func ParseNatural(spec string) (Scheduler, error) {
+	n := &natural{spec: spec, from: -1, to: -1}
+	var err error
+	if strings.IndexFunc(spec, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0 {
+		err = n.parseZh()
+	} else {
+		err = n.parseEn()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d, err := n.duration(); d != nil || err != nil {
+		return d, err
+	}
+
+	expr, err := n.expr()
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(expr)
+	if err != nil {
+		reason := err.Error()
+		if pe, ok := err.(*ParseError); ok {
+			reason = pe.Reason
+		}
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: reason}
+	}
+	return s, nil
+}
+
+// naturalTime 短语中的时间
+type naturalTime struct {
+	hour, min, sec int
+}
+
+// natural 短语解析的结果
+type natural struct {
+	spec string
+
+	// 间隔执行: unit 为 's'、'm'、'h'，interval 为 0 时没有指定
+	interval    int
+	unit        byte
+	intervalTok field
+
+	// 间隔执行的时间范围(小时)，没有指定时为 -1
+	from, to int
+
+	times    []naturalTime
+	days     []string
+	weekDays uint64
+	months   uint64
+	matched  bool
+}
+
+// errorf 生成 tok 处的解析错误
+func (n *natural) errorf(tok field, reason string) error {
+	return newParseError(n.spec, tok, -1, reason)
+}
+
+// setInterval 设置间隔执行
+func (n *natural) setInterval(tok field, v int, unit byte) error {
+	if n.interval > 0 {
+		return n.errorf(tok, "duplicate interval")
+	}
+	if v < 1 {
+		return n.errorf(tok, "interval must be positive")
+	}
+	n.interval, n.unit, n.intervalTok, n.matched = v, unit, tok, true
+	return nil
+}
+
+// addDay 添加每月的日期，v 为 0 时表示最后一天
+func (n *natural) addDay(tok field, v int) error {
+	if v < 0 || v > 31 {
+		return n.errorf(tok, "day must be between 1 and 31")
+	}
+	if v == 0 {
+		n.days = append(n.days, "L")
+	} else {
+		n.days = append(n.days, strconv.Itoa(v))
+	}
+	n.matched = true
+	return nil
+}
+
+// addTime 添加执行时间
+func (n *natural) addTime(tok field, t naturalTime) error {
+	if t.hour < 0 || t.hour > 23 || t.min < 0 || t.min > 59 || t.sec < 0 || t.sec > 59 {
+		return n.errorf(tok, "invalid time")
+	}
+	n.times = append(n.times, t)
+	n.matched = true
+	return nil
+}
+
+// setRange 设置间隔执行的时间范围
+func (n *natural) setRange(tok field, from, to naturalTime) error {
+	if from.min != 0 || from.sec != 0 || to.min != 0 || to.sec != 0 {
+		return n.errorf(tok, "time range must be whole hours")
+	}
+	if from.hour >= to.hour {
+		return n.errorf(tok, "time range must end after it starts")
+	}
+	n.from, n.to, n.matched = from.hour, to.hour, true
+	return nil
+}
+
+// duration 间隔不能整除周期时返回按固定间隔执行的调度器，能用表达式表示时返回 nil
+// */N 在 N 不能整除周期时，跨周期的两次执行间隔变短，如 */7 分钟在 :56 和 :00 执行
+func (n *natural) duration() (*DurationSchedule, error) {
+	cycle := 60
+	if n.unit == 'h' {
+		cycle = 24
+	}
+	if n.interval == 0 || len(n.times) > 0 || cycle%n.interval == 0 || (n.unit == 'h' && n.from >= 0) {
+		return nil, nil
+	}
+	if n.from >= 0 || len(n.days) > 0 || n.weekDays != 0 || n.months != 0 {
+		return nil, n.errorf(n.intervalTok, fmt.Sprintf("interval must divide %d when days or time range are given", cycle))
+	}
+	unit := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour}[n.unit]
+	return &DurationSchedule{start: time.Now().Truncate(unit), frequency: time.Duration(n.interval) * unit}, nil
+}
+
+// expr 生成 秒 分 时 日 月 星期 格式的表达式
+func (n *natural) expr() (string, error) {
+	if !n.matched {
+		return "", &ParseError{Expr: n.spec, Field: -1, Offset: -1, Reason: "missing schedule"}
+	}
+
+	var sec, min, hour string
+	switch {
+	case n.interval > 0 && len(n.times) > 0:
+		return "", &ParseError{Expr: n.spec, Field: -1, Offset: -1, Reason: "interval can not be combined with specific times"}
+	case n.interval > 0:
+		hours := "*"
+		if n.from >= 0 {
+			hours = fmt.Sprintf("%d-%d", n.from, n.to-1)
+		}
+		step := fmt.Sprintf("*/%d", n.interval)
+		switch n.unit {
+		case 's':
+			sec, min, hour = step, "*", hours
+		case 'm':
+			sec, min, hour = "0", step, hours
+		case 'h':
+			sec, min, hour = "0", "0", step
+			if n.from >= 0 {
+				hour = fmt.Sprintf("%d-%d/%d", n.from, n.to, n.interval)
+			}
+		}
+	case n.from >= 0:
+		return "", &ParseError{Expr: n.spec, Field: -1, Offset: -1, Reason: "time range requires an interval"}
+	case len(n.times) == 0:
+		sec, min, hour = "0", "0", "0"
+	default:
+		var err error
+		if sec, min, hour, err = n.clock(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := n.checkDays(); err != nil {
+		return "", err
+	}
+	day, week, month := "*", "*", "*"
+	if len(n.days) > 0 {
+		day = strings.Join(n.days, ",")
+	}
+	if n.weekDays != 0 {
+		week = formatField(n.weekDays, weekDays)
+	}
+	if n.months != 0 {
+		month = formatField(n.months, months)
+	}
+	return strings.Join([]string{sec, min, hour, day, month, week}, " "), nil
+}
+
+// checkDays 指定月份时日期需要在其中某个月份中存在，如 2 月 30 日永远不会执行
+func (n *natural) checkDays() error {
+	if n.months == 0 {
+		return nil
+	}
+	for _, d := range n.days {
+		v, err := strconv.Atoi(d)
+		if err != nil {
+			continue
+		}
+		exist := false
+		for m := time.January; m <= time.December; m++ {
+			// 闰年的天数，2 月 29 日可以执行
+			if n.months&(1<<uint(m)) > 0 && v <= daysIn(2020, m) {
+				exist = true
+			}
+		}
+		if !exist {
+			return &ParseError{Expr: n.spec, Field: -1, Offset: -1, Reason: fmt.Sprintf("day %d does not exist in the given months", v)}
+		}
+	}
+	return nil
+}
+
+// clock 将执行时间组合为秒、分、时字段，无法组合时返回错误
+func (n *natural) clock() (sec, min, hour string, err error) {
+	var hs, ms, ss uint64
+	set := map[naturalTime]bool{}
+	for _, t := range n.times {
+		hs, ms, ss = hs|1<<uint(t.hour), ms|1<<uint(t.min), ss|1<<uint(t.sec)
+		set[t] = true
+	}
+	count := func(v uint64) int {
+		c := 0
+		for ; v > 0; v &= v - 1 {
+			c++
+		}
+		return c
+	}
+	if count(hs)*count(ms)*count(ss) != len(set) {
+		return "", "", "", &ParseError{Expr: n.spec, Field: -1, Offset: -1, Reason: "times can not be combined into one schedule"}
+	}
+	return formatField(ss, seconds), formatField(ms, minutes), formatField(hs, hours), nil
+}
+
+// 英文短语
+
+// enTokenRe 英文短语的词: 数字开头的时间或序数(9:30am、1st)、单词、其它单个字符
+var enTokenRe = regexp.MustCompile(`\d+(?::\d+)*[A-Za-z]*|[A-Za-z]+|\S`)
+
+// enTimeRe 英文时间，如 9、9:30、9:30:15、9am、9:30pm
+var enTimeRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?(am|pm)?$`)
+
+// enOrdinalRe 英文日期，如 1、1st、22nd
+var enOrdinalRe = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+
+var enWeekNames = map[string]uint64{
+	"sunday": 0, "sun": 0, "monday": 1, "mon": 1, "tuesday": 2, "tue": 2, "tues": 2,
+	"wednesday": 3, "wed": 3, "thursday": 4, "thu": 4, "thur": 4, "thurs": 4,
+	"friday": 5, "fri": 5, "saturday": 6, "sat": 6,
+}
+
+var enMonthNames = map[string]uint64{
+	"january": 1, "february": 2, "march": 3, "april": 4, "june": 6, "july": 7,
+	"august": 8, "september": 9, "sept": 9, "october": 10, "november": 11, "december": 12,
+}
+
+// enUnits 间隔的单位
+var enUnits = map[string]byte{
+	"second": 's', "seconds": 's', "sec": 's', "secs": 's',
+	"minute": 'm', "minutes": 'm', "min": 'm', "mins": 'm',
+	"hour": 'h', "hours": 'h',
+}
+
+// enParser 英文短语解析器
+type enParser struct {
+	*natural
+	tokens []field
+	i      int
+}
+
+// parseEn 解析英文短语
+func (n *natural) parseEn() error {
+	p := &enParser{natural: n}
+	for _, loc := range enTokenRe.FindAllStringIndex(n.spec, -1) {
+		p.tokens = append(p.tokens, field{text: n.spec[loc[0]:loc[1]], offset: loc[0], index: len(p.tokens)})
+	}
+
+	for p.i < len(p.tokens) {
+		tok := p.next()
+		var err error
+		switch word := strings.ToLower(tok.text); word {
+		case ",", "and":
+		case "every", "each":
+			err = p.every()
+		case "on":
+			err = p.on()
+		case "in":
+			err = p.in()
+		case "at":
+			err = p.times()
+		case "between", "from":
+			err = p.between(tok)
+		case "hourly":
+			err = n.setInterval(tok, 1, 'h')
+		case "daily", "nightly":
+			n.matched = true
+		case "weekly":
+			n.weekDays, n.matched = n.weekDays|1, true
+		case "monthly":
+			err = n.addDay(tok, 1)
+		case "yearly", "annually":
+			n.months |= 1 << 1
+			err = n.addDay(tok, 1)
+		case "weekdays":
+			n.weekDays, n.matched = n.weekDays|0x3E, true
+		case "weekends":
+			n.weekDays, n.matched = n.weekDays|0x41, true
+		default:
+			p.i--
+			if !p.isTime() {
+				return n.errorf(tok, "unrecognized phrase")
+			}
+			err = p.times()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// peek 下一个词(小写)，没有时返回空字符串
+func (p *enParser) peek() string {
+	if p.i < len(p.tokens) {
+		return strings.ToLower(p.tokens[p.i].text)
+	}
+	return ""
+}
+
+// next 读取下一个词，没有时返回位于表达式末尾的空词
+func (p *enParser) next() field {
+	if p.i < len(p.tokens) {
+		p.i++
+		return p.tokens[p.i-1]
+	}
+	return field{offset: len(p.spec), index: len(p.tokens)}
+}
+
+// skip 下一个词为 words 之一时跳过
+func (p *enParser) skip(words ...string) bool {
+	for _, w := range words {
+		if p.peek() == w {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+// every 解析 every 之后的内容
+func (p *enParser) every() error {
+	tok := p.next()
+	word := strings.ToLower(tok.text)
+	if v, err := strconv.Atoi(word); err == nil {
+		unitTok := p.next()
+		unit, ok := enUnits[strings.ToLower(unitTok.text)]
+		if !ok {
+			return p.errorf(unitTok, "expected seconds, minutes or hours")
+		}
+		return p.setInterval(tok, v, unit)
+	}
+	if unit, ok := enUnits[word]; ok {
+		return p.setInterval(tok, 1, unit)
+	}
+
+	switch word {
+	case "day", "night":
+		p.matched = true
+	case "weekday":
+		p.weekDays, p.matched = p.weekDays|0x3E, true
+	case "weekend":
+		p.weekDays, p.matched = p.weekDays|0x41, true
+	case "week":
+		p.weekDays, p.matched = p.weekDays|1, true
+	case "month":
+		// 没有指定日期时为每月 1 日
+		if p.peek() != "on" {
+			return p.addDay(tok, 1)
+		}
+		p.matched = true
+	case "year":
+		if p.peek() != "on" && p.peek() != "in" {
+			p.months |= 1 << 1
+			return p.addDay(tok, 1)
+		}
+		p.matched = true
+	default:
+		p.i--
+		return p.weekList()
+	}
+	return nil
+}
+
+// on 解析 on 之后的内容
+func (p *enParser) on() error {
+	tok := p.next()
+	word := strings.ToLower(tok.text)
+	switch {
+	case word == "weekdays":
+		p.weekDays, p.matched = p.weekDays|0x3E, true
+	case word == "weekends":
+		p.weekDays, p.matched = p.weekDays|0x41, true
+	case word == "the":
+		if err := p.dayList(); err != nil {
+			return err
+		}
+		if p.skip("of") {
+			p.skip("the", "every", "each")
+			if !p.skip("month") {
+				return p.in()
+			}
+		}
+	case isEnMonth(word):
+		p.i--
+		if err := p.in(); err != nil {
+			return err
+		}
+		return p.dayList()
+	default:
+		p.i--
+		return p.weekList()
+	}
+	return nil
+}
+
+// in 解析月份列表，如 january and july
+func (p *enParser) in() error {
+	for {
+		tok := p.next()
+		word := strings.ToLower(tok.text)
+		if !isEnMonth(word) {
+			return p.errorf(tok, "expected month name")
+		}
+		p.months, p.matched = p.months|1<<enMonth(word), true
+		if !p.listNext(isEnMonth) {
+			return nil
+		}
+	}
+}
+
+// listNext 下一个词是列表的分隔符且之后是列表项时跳过分隔符
+func (p *enParser) listNext(item func(string) bool) bool {
+	i := p.i
+	for p.skip(",", "and") {
+	}
+	if p.i < len(p.tokens) && item(p.peek()) {
+		return true
+	}
+	p.i = i
+	return false
+}
+
+// weekList 解析星期列表，如 mon, wed and fri、monday-friday、mondays
+func (p *enParser) weekList() error {
+	for {
+		tok := p.next()
+		start, ok := enWeekDay(tok.text)
+		if !ok {
+			return p.errorf(tok, "unrecognized phrase")
+		}
+		end := start
+		if p.skip("-", "to", "through", "thru") {
+			endTok := p.next()
+			if end, ok = enWeekDay(endTok.text); !ok {
+				return p.errorf(endTok, "expected weekday")
+			}
+		}
+		for w := start; ; w = (w + 1) % 7 {
+			p.weekDays |= 1 << w
+			if w == end {
+				break
+			}
+		}
+		p.matched = true
+		if !p.listNext(func(s string) bool { _, ok := enWeekDay(s); return ok }) {
+			return nil
+		}
+	}
+}
+
+// dayList 解析日期列表，如 1st and 15th、last day
+func (p *enParser) dayList() error {
+	isDay := func(s string) bool { return s == "last" || enOrdinalRe.MatchString(s) }
+	for {
+		tok := p.next()
+		word := strings.ToLower(tok.text)
+		switch m := enOrdinalRe.FindStringSubmatch(word); {
+		case word == "last":
+			p.skip("day")
+			if err := p.addDay(tok, 0); err != nil {
+				return err
+			}
+		case m != nil:
+			v, _ := strconv.Atoi(m[1])
+			if err := p.addDay(tok, v); err != nil {
+				return err
+			}
+		default:
+			return p.errorf(tok, "expected day of month")
+		}
+		if !p.listNext(isDay) {
+			return nil
+		}
+	}
+}
+
+// isTime 下一个词是否为时间
+func (p *enParser) isTime() bool {
+	word := p.peek()
+	return word == "noon" || word == "midnight" || enTimeRe.MatchString(word)
+}
+
+// time 解析时间，如 9:30、9am、9 pm、noon
+func (p *enParser) time() (naturalTime, field, error) {
+	tok := p.next()
+	word := strings.ToLower(tok.text)
+	switch word {
+	case "noon":
+		return naturalTime{hour: 12}, tok, nil
+	case "midnight":
+		return naturalTime{}, tok, nil
+	}
+
+	m := enTimeRe.FindStringSubmatch(word)
+	if m == nil {
+		return naturalTime{}, tok, p.errorf(tok, "expected time")
+	}
+	var t naturalTime
+	t.hour, _ = strconv.Atoi(m[1])
+	t.min, _ = strconv.Atoi("0" + m[2])
+	t.sec, _ = strconv.Atoi("0" + m[3])
+	suffix := m[4]
+	if suffix == "" && (p.peek() == "am" || p.peek() == "pm") {
+		suffix = p.peek()
+		p.i++
+	}
+	if suffix != "" {
+		if t.hour < 1 || t.hour > 12 {
+			return t, tok, p.errorf(tok, "invalid time")
+		}
+		t.hour %= 12
+		if suffix == "pm" {
+			t.hour += 12
+		}
+	}
+	return t, tok, nil
+}
+
+// times 解析时间列表，如 9am and 6pm
+func (p *enParser) times() error {
+	for {
+		t, tok, err := p.time()
+		if err != nil {
+			return err
+		}
+		if err := p.addTime(tok, t); err != nil {
+			return err
+		}
+		if !p.listNext(func(s string) bool { return s == "noon" || s == "midnight" || enTimeRe.MatchString(s) }) {
+			return nil
+		}
+	}
+}
+
+// between 解析时间范围，如 between 9:00 and 17:00、from 9am to 5pm
+func (p *enParser) between(tok field) error {
+	from, _, err := p.time()
+	if err != nil {
+		return err
+	}
+	if sep := p.next(); !strings.EqualFold(sep.text, "and") && !strings.EqualFold(sep.text, "to") {
+		return p.errorf(sep, "expected and or to")
+	}
+	to, _, err := p.time()
+	if err != nil {
+		return err
+	}
+	return p.setRange(tok, from, to)
+}
+
+// enWeekDay 英文星期，可以是复数，如 mondays
+func enWeekDay(s string) (uint64, bool) {
+	s = strings.ToLower(s)
+	if w, ok := enWeekNames[s]; ok {
+		return w, true
+	}
+	w, ok := enWeekNames[strings.TrimSuffix(s, "s")]
+	return w, ok && strings.HasSuffix(s, "days")
+}
+
+// isEnMonth 是否为英文月份
+func isEnMonth(s string) bool {
+	return enMonth(s) > 0
+}
+
+// enMonth 英文月份，不是月份时返回 0
+func enMonth(s string) uint64 {
+	if v, ok := enMonthNames[s]; ok {
+		return v
+	}
+	return monthNames[s]
+}
+
+// 中文短语
+
+// zhPeriods 时段，返回调整后的小时
+var zhPeriods = []struct {
+	name   string
+	adjust func(h int) int
+}{
+	{"凌晨", func(h int) int { return h % 12 }},
+	{"早上", func(h int) int { return h }},
+	{"早晨", func(h int) int { return h }},
+	{"上午", func(h int) int { return h }},
+	{"中午", func(h int) int {
+		if h < 11 {
+			return h + 12
+		}
+		return h
+	}},
+	{"下午", pm},
+	{"傍晚", pm},
+	{"晚上", night},
+	{"夜里", night},
+}
+
+// pm 下午、晚上的小时
+func pm(h int) int {
+	if h < 12 {
+		return h + 12
+	}
+	return h
+}
+
+// night 晚上、夜里的小时，12 点表示午夜
+func night(h int) int {
+	if h == 12 {
+		return 0
+	}
+	return pm(h)
+}
+
+// zhUnits 间隔的单位，较长的在前
+var zhUnits = []struct {
+	name string
+	unit byte
+}{
+	{"秒钟", 's'}, {"秒", 's'}, {"分钟", 'm'}, {"分", 'm'}, {"个小时", 'h'}, {"小时", 'h'}, {"个钟头", 'h'}, {"钟头", 'h'},
+}
+
+// zhWeekNames 星期的中文数字
+var zhWeekNames = map[rune]uint64{'日': 0, '天': 0, '一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 0}
+
+// zhDigits 中文数字
+var zhDigits = map[rune]int{'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// zhParser 中文短语解析器
+type zhParser struct {
+	*natural
+	pos int
+}
+
+// parseZh 解析中文短语
+func (n *natural) parseZh() error {
+	p := &zhParser{natural: n}
+	for {
+		p.skipSeparators()
+		if p.pos >= len(p.spec) {
+			return nil
+		}
+
+		var err error
+		start := p.pos
+		switch {
+		case p.consume("每隔") || p.consume("每"):
+			err = p.every(start)
+		case p.consume("天天"):
+			p.matched = true
+		case p.consume("工作日"):
+			p.weekDays, p.matched = p.weekDays|0x3E, true
+		case p.consume("周末"):
+			p.weekDays, p.matched = p.weekDays|0x41, true
+		case p.peekWeek():
+			err = p.weekList()
+		default:
+			err = p.times()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rest 从当前位置到下一个分隔符的内容，用于错误提示
+func (p *zhParser) rest(start int) field {
+	end := strings.IndexFunc(p.spec[start:], func(r rune) bool { return unicode.IsSpace(r) || r == '，' || r == ',' })
+	if end < 0 {
+		end = len(p.spec) - start
+	}
+	return field{text: p.spec[start : start+end], offset: start, index: -1}
+}
+
+// token start 到当前位置的内容
+func (p *zhParser) token(start int) field {
+	return field{text: p.spec[start:p.pos], offset: start, index: -1}
+}
+
+// consume 当前位置为 s 时跳过
+func (p *zhParser) consume(s string) bool {
+	if strings.HasPrefix(p.spec[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// skipSeparators 跳过空白、标点及 和、的、在 等连接词
+func (p *zhParser) skipSeparators() {
+	for p.pos < len(p.spec) {
+		r, size := utf8.DecodeRuneInString(p.spec[p.pos:])
+		if !unicode.IsSpace(r) && !strings.ContainsRune("，,、和及的在。", r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// number 解析阿拉伯数字或中文数字(0-99)
+func (p *zhParser) number() (int, bool) {
+	start := p.pos
+	for p.pos < len(p.spec) && p.spec[p.pos] >= '0' && p.spec[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos > start {
+		v, err := strconv.Atoi(p.spec[start:p.pos])
+		return v, err == nil
+	}
+
+	// 中文数字: 五、十、十五、二十、二十三
+	v, tens := 0, false
+	for p.pos < len(p.spec) {
+		r, size := utf8.DecodeRuneInString(p.spec[p.pos:])
+		if d, ok := zhDigits[r]; ok && !tens || ok && v%10 == 0 && tens {
+			v += d
+		} else if r == '十' && !tens {
+			if v == 0 {
+				v = 1
+			}
+			v, tens = v*10, true
+		} else {
+			break
+		}
+		p.pos += size
+		if !tens && v >= 10 {
+			break
+		}
+	}
+	return v, p.pos > start
+}
+
+// every 解析 每 之后的内容
+func (p *zhParser) every(start int) error {
+	if v, ok := p.number(); ok {
+		for _, u := range zhUnits {
+			if p.consume(u.name) {
+				return p.setInterval(p.token(start), v, u.unit)
+			}
+		}
+		return p.errorf(p.rest(start), "expected 秒, 分钟 or 小时")
+	}
+	for _, u := range zhUnits {
+		if p.consume(u.name) {
+			return p.setInterval(p.token(start), 1, u.unit)
+		}
+	}
+
+	// 每个月、每个工作日、每个星期一
+	p.consume("个")
+	switch {
+	case p.consume("天"), p.consume("日"):
+		p.matched = true
+	case p.consume("工作日"):
+		p.weekDays, p.matched = p.weekDays|0x3E, true
+	case p.consume("周末"):
+		p.weekDays, p.matched = p.weekDays|0x41, true
+	case p.peekWeek():
+		return p.weekList()
+	case p.consume("月"):
+		return p.dayList(start)
+	case p.consume("年"):
+		return p.date(start)
+	default:
+		return p.errorf(p.rest(start), "unrecognized phrase")
+	}
+	return nil
+}
+
+// peekWeek 当前位置是否为 周、星期、礼拜
+func (p *zhParser) peekWeek() bool {
+	for _, prefix := range []string{"周", "星期", "礼拜"} {
+		if strings.HasPrefix(p.spec[p.pos:], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekDay 解析 [周|星期|礼拜]一 或 1，prefix 表示是否必须有前缀
+func (p *zhParser) weekDay(prefix bool) (uint64, bool) {
+	start := p.pos
+	if !p.consume("周") && !p.consume("星期") && !p.consume("礼拜") && prefix {
+		return 0, false
+	}
+	r, size := utf8.DecodeRuneInString(p.spec[p.pos:])
+	if w, ok := zhWeekNames[r]; ok {
+		p.pos += size
+		return w, true
+	}
+	if r >= '1' && r <= '7' {
+		p.pos++
+		return uint64(r-'0') % 7, true
+	}
+	p.pos = start
+	return 0, false
+}
+
+// weekList 解析星期列表，如 周一、三、五、周一到周五
+func (p *zhParser) weekList() error {
+	start := p.pos
+	first := true
+	for {
+		w, ok := p.weekDay(first)
+		if !ok {
+			if first {
+				return p.errorf(p.rest(start), "expected weekday")
+			}
+			return nil
+		}
+		end := w
+		if p.consume("到") || p.consume("至") || p.consume("-") || p.consume("~") {
+			if end, ok = p.weekDay(false); !ok {
+				return p.errorf(p.rest(start), "expected weekday")
+			}
+		}
+		for d := w; ; d = (d + 1) % 7 {
+			p.weekDays |= 1 << d
+			if d == end {
+				break
+			}
+		}
+		p.matched, first = true, false
+
+		// 列表项之间用 、 , 和 分割
+		sep := p.pos
+		if !p.consume("、") && !p.consume(",") && !p.consume("，") && !p.consume("和") {
+			return nil
+		}
+		if _, ok := p.weekDay(false); !ok {
+			p.pos = sep
+			return nil
+		}
+		p.pos = sep
+		p.skipSeparators()
+	}
+}
+
+// dayList 解析每月的日期列表，如 1号、1日和15日、最后一天
+func (p *zhParser) dayList(start int) error {
+	for {
+		p.consume("的")
+		itemStart := p.pos
+		switch {
+		case p.consume("最后一天"), p.consume("月底"), p.consume("最后1天"):
+			if err := p.addDay(p.token(itemStart), 0); err != nil {
+				return err
+			}
+		default:
+			v, ok := p.number()
+			if !ok || !p.consume("号") && !p.consume("日") {
+				return p.errorf(p.rest(start), "expected day of month")
+			}
+			if err := p.addDay(p.token(itemStart), v); err != nil {
+				return err
+			}
+		}
+
+		sep := p.pos
+		p.skipSeparators()
+		r, _ := utf8.DecodeRuneInString(p.spec[p.pos:])
+		if sep == p.pos || !(r >= '0' && r <= '9' || zhDigits[r] > 0 || r == '十' || strings.HasPrefix(p.spec[p.pos:], "最后")) {
+			p.pos = sep
+			return nil
+		}
+		// 分隔符之后是时间而不是日期
+		if save := p.pos; true {
+			if _, ok := p.number(); ok && (p.consume("点") || p.consume("时") || p.consume(":")) {
+				p.pos = sep
+				return nil
+			}
+			p.pos = save
+		}
+	}
+}
+
+// date 解析每年的日期，如 1月1日
+func (p *zhParser) date(start int) error {
+	p.consume("的")
+	itemStart := p.pos
+	m, ok := p.number()
+	if !ok || !p.consume("月") {
+		return p.errorf(p.rest(start), "expected month")
+	}
+	if m < 1 || m > 12 {
+		return p.errorf(p.token(itemStart), "month must be between 1 and 12")
+	}
+	p.months |= 1 << uint(m)
+	return p.dayList(start)
+}
+
+// time 解析时间，如 早上8点、下午3点半、9:30
+func (p *zhParser) time() (naturalTime, field, bool, error) {
+	start := p.pos
+	adjust := func(h int) int { return h }
+	for _, period := range zhPeriods {
+		if p.consume(period.name) {
+			adjust = period.adjust
+			break
+		}
+	}
+
+	var t naturalTime
+	h, ok := p.number()
+	if !ok {
+		p.pos = start
+		return t, field{}, false, nil
+	}
+	switch {
+	case p.consume(":") || p.consume("："):
+		if t.min, ok = p.number(); !ok {
+			return t, field{}, true, p.errorf(p.rest(start), "invalid time")
+		}
+	case p.consume("点") || p.consume("时"):
+		switch {
+		case p.consume("半"):
+			t.min = 30
+		case p.consume("一刻"):
+			t.min = 15
+		case p.consume("三刻"):
+			t.min = 45
+		case p.consume("整"):
+		default:
+			save := p.pos
+			if v, ok := p.number(); ok && (p.consume("分") || !strings.HasPrefix(p.spec[p.pos:], "号") && !strings.HasPrefix(p.spec[p.pos:], "日")) {
+				t.min = v
+				if v, ok := p.number(); ok && p.consume("秒") {
+					t.sec = v
+				}
+			} else {
+				p.pos = save
+			}
+		}
+	default:
+		p.pos = start
+		return t, field{}, false, nil
+	}
+	t.hour = adjust(h)
+	return t, p.token(start), true, nil
+}
+
+// times 解析时间或时间范围，如 8点、9点到17点之间
+func (p *zhParser) times() error {
+	start := p.pos
+	t, tok, ok, err := p.time()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return p.errorf(p.rest(start), "unrecognized phrase")
+	}
+
+	if p.consume("到") || p.consume("至") || p.consume("-") || p.consume("~") {
+		to, _, ok, err := p.time()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return p.errorf(p.rest(start), "expected time")
+		}
+		if !p.consume("之间") {
+			p.consume("间")
+		}
+		return p.setRange(p.token(start), t, to)
+	}
+	return p.addTime(tok, t)
+}
@@ -0,0 +1,154 @@
+package corn
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseNatural(t *testing.T) {
+	data := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"工作日", "every weekday at 9:30", "0 30 9 * * 1-5"},
+		{"每天", "daily at 6pm", "0 0 18 * * *"},
+		{"多个时间", "every day at 9am and 9pm", "0 0 9,21 * * *"},
+		{"中午", "every day at noon", "0 0 12 * * *"},
+		{"分开的 am", "every sunday at 10 am", "0 0 10 * * 0"},
+		{"星期列表", "every mon, wed and fri at 8:15", "0 15 8 * * 1/2"},
+		{"星期区间", "every tuesday through thursday at 7:00", "0 0 7 * * 2-4"},
+		{"复数星期", "on saturdays and sundays at 11:30:15", "15 30 11 * * 0,6"},
+		{"周末", "every weekend at midnight", "0 0 0 * * 0,6"},
+		{"间隔", "every 15 minutes", "0 */15 * * * *"},
+		{"每小时", "hourly", "0 0 * * * *"},
+		{"每秒", "every 10 seconds", "*/10 * * * * *"},
+		{"时间范围", "every 15 minutes between 9:00 and 17:00 on weekdays", "0 */15 9-16 * * 1-5"},
+		{"小时范围", "every 2 hours from 8am to 8pm", "0 0 8-20/2 * * *"},
+		{"每月", "monthly", "0 0 0 1 * *"},
+		{"每月日期", "every month on the 1st and 15th at 8am", "0 0 8 1,15 * *"},
+		{"月末", "every month on the last day at 6pm", "0 0 18 L * *"},
+		{"日期", "on the 10th of the month at 10:10", "0 10 10 10 * *"},
+		{"每年", "yearly", "0 0 0 1 1 *"},
+		{"每年日期", "every year on december 25th at 7am", "0 0 7 25 12 *"},
+		{"月份", "on the 1st of january and july at noon", "0 0 12 1 1,7 *"},
+		{"in 月份", "every monday in march", "0 0 0 * 3 1"},
+
+		{"中文每月", "每月1号早上8点", "0 0 8 1 * *"},
+		{"中文工作日", "每个工作日上午9点半", "0 30 9 * * 1-5"},
+		{"中文周末", "周末晚上8点15分", "0 15 20 * * 0,6"},
+		{"中文每天", "每天下午3点", "0 0 15 * * *"},
+		{"中文冒号", "每天 9:30", "0 30 9 * * *"},
+		{"中文星期列表", "每周一、三、五早上七点", "0 0 7 * * 1/2"},
+		{"中文星期区间", "周一到周五 中午12点", "0 0 12 * * 1-5"},
+		{"星期日", "每个星期天凌晨两点", "0 0 2 * * 0"},
+		{"中文间隔", "每隔15分钟", "0 */15 * * * *"},
+		{"中文每小时", "每小时", "0 0 * * * *"},
+		{"中文时间范围", "工作日9点到18点之间每30分钟", "0 0,30 9-17 * * 1-5"},
+		{"中文数字", "每月十五号晚上十一点", "0 0 23 15 * *"},
+		{"多个日期", "每月1日和15日 8点", "0 0 8 1,15 * *"},
+		{"中文月末", "每月最后一天下午6点", "0 0 18 L * *"},
+		{"中文每年", "每年12月25日早上7点", "0 0 7 25 12 *"},
+		{"闰日", "on feb 29", "0 0 0 29 2 *"},
+		{"中文多个时间", "每天早上8点和晚上8点", "0 0 8,20 * * *"},
+		{"中文午夜", "每天晚上12点", "0 0 0 * * *"},
+		{"中文夜里", "每天夜里12点半", "0 30 0 * * *"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := ParseNatural(p.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts, ok := s.(*TimeSchedule)
+			if !ok {
+				t.Fatalf("get: %T", s)
+			}
+			if get := ts.String(); get != p.want {
+				t.Errorf("want: %s, get: %s", p.want, get)
+			}
+		})
+	}
+}
+
+func Test_ParseNaturalError(t *testing.T) {
+	data := []struct {
+		name   string
+		spec   string
+		token  string
+		offset int
+		reason string
+	}{
+		{"空", "", "", -1, "missing schedule"},
+		{"无法识别", "every weekday at tea time", "tea", 17, "expected time"},
+		{"未知单词", "sometimes at 9", "sometimes", 0, "unrecognized phrase"},
+		{"间隔单位", "every 5 days", "days", 8, "expected seconds, minutes or hours"},
+		{"间隔为 0", "every 0 minutes", "0", 6, "interval must be positive"},
+		{"间隔不能整除和星期", "every 7 minutes on monday", "7", 6, "interval must divide 60 when days or time range are given"},
+		{"间隔不能整除和时间范围", "every 7 minutes between 9:00 and 17:00", "7", 6, "interval must divide 60 when days or time range are given"},
+		{"中文间隔不能整除和日期", "每月1号每7秒", "每7秒", 10, "interval must divide 60 when days or time range are given"},
+		{"不存在的日期", "on feb 30", "", -1, "day 30 does not exist in the given months"},
+		{"中文不存在的日期", "每年2月30日", "", -1, "day 30 does not exist in the given months"},
+		{"无效时间", "every day at 13pm", "13pm", 13, "invalid time"},
+		{"无效日期", "on the 32nd", "32nd", 7, "day must be between 1 and 31"},
+		{"间隔和时间", "every 5 minutes at 9:00", "", -1, "interval can not be combined with specific times"},
+		{"无法组合", "every day at 8:30 and 18:00", "", -1, "times can not be combined into one schedule"},
+		{"范围", "every hour between 17:00 and 9:00", "between", 11, "time range must end after it starts"},
+		{"中文无法识别", "每天喝茶", "喝茶", 6, "unrecognized phrase"},
+		{"中文间隔单位", "每隔3天", "每隔3天", 0, "expected 秒, 分钟 or 小时"},
+		{"中文日期", "每月三十二号", "三十二号", 6, "day must be between 1 and 31"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := ParseNatural(p.spec)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("err: %v", err)
+			}
+			if pe.Token != p.token || pe.Offset != p.offset || pe.Reason != p.reason {
+				t.Errorf("token: %q, offset: %d, reason: %q", pe.Token, pe.Offset, pe.Reason)
+			}
+		})
+	}
+}
+
+// 间隔执行时相邻两次执行的间隔都相同(时间范围之间除外)
+func Test_ParseNaturalInterval(t *testing.T) {
+	data := []struct {
+		spec string
+		gap  time.Duration
+	}{
+		{"every 15 seconds", 15 * time.Second},
+		{"every 20 minutes", 20 * time.Minute},
+		{"every 6 hours", 6 * time.Hour},
+		{"每隔3小时", 3 * time.Hour},
+		{"every 7 minutes", 7 * time.Minute},
+		{"every 45 minutes", 45 * time.Minute},
+		{"every 90 minutes", 90 * time.Minute},
+		{"every 5 hours", 5 * time.Hour},
+		{"每7秒", 7 * time.Second},
+	}
+
+	from := time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local)
+	for _, p := range data {
+		t.Run(p.spec, func(t *testing.T) {
+			s, err := ParseNatural(p.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := NextN(s, from, 200)
+			for i := 1; i < len(get); i++ {
+				if gap := get[i].Sub(get[i-1]); gap != p.gap {
+					t.Fatalf("%s 到 %s want: %s, get: %s", get[i-1], get[i], p.gap, gap)
+				}
+			}
+		})
+	}
+
+	// 指定时间范围时小时间隔不需要整除 24
+	if _, err := ParseNatural("every 5 hours between 8:00 and 20:00"); err != nil {
+		t.Error(err)
+	}
+}
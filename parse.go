@@ -1,6 +1,7 @@
 package corn
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,8 +17,8 @@ import (
 // | | . --------- 小时：0-23
 // | | | .-------- 日期：1-31
 // | | | | .------ 月份：1-12
-// | | | | | .---- 星期：0-6（0 表示周日）
-// | | | | | | .-- 年:  0-63(如果起始年是2019, 0表示2019年, 10表示2029年)
+// | | | | | .---- 星期：0-7（0 和 7 都表示周日）
+// | | | | | | .-- 年:  2019-2082(StartYear 到 StartYear+63, 可省略)
 // | | | | | | |
 // * * * * * * *
 //
@@ -26,79 +27,294 @@ import (
 // ,:   表示分割，如第三段里：2,4，表示 2 点和 4 点执行
 // －:  表示一个段，如第三端里： 1-5，就表示 1 到 5 点
 // /n:  表示每个n的单位执行一次，如第三段里，*/1, 就表示每隔 1 个小时执行一次命令。也可以写成1-23/1.
+//      m/n 表示从 m 开始到字段最大值每隔 n 执行一次，如第三段里，2/8 表示 2、10、18 点执行
+//      结束值小于起始值时跨越周期(年字段除外)，如第三段里，22-2 表示 22、23、0、1、2 点，22-2/2 表示 22、0、2 点
+//      * 表示字段的完整范围(秒、分 0-59，时 0-23，日 1-31，月 1-12，星期 0-6)，如第四段里，*/10 表示 1、11、21、31 日执行
+//
+// 月份和星期还可以使用英文缩写(不区分大小写)，可用于范围和列表中：
+// 月份: JAN-DEC
+// 星期: SUN-SAT
+//
+// 日期和星期字段还支持以下符号，可与其它取值用 ',' 组合：
+// ?:   不指定值，等同于 *
+// L:   日期字段表示每月最后一天，L-n 表示每月倒数第 n+1 天；星期字段单独使用表示周六
+// LW:  日期字段表示每月最后一个工作日(周一至周五)
+// nW:  日期字段表示离每月 n 日最近的工作日，不会跨月，如 15W
+// nL:  星期字段表示每月最后一个星期 n，如 5L 表示每月最后一个周五
+// n#k: 星期字段表示每月第 k 个星期 n，如 2#2 表示每月第二个周二
+//
+// 取值超出字段范围时返回错误，如需忽略超出范围的取值请使用 WithLenient 创建解析器
+// H 表示由 key 确定的固定取值，用于分散大量相同表达式的执行时间，需要使用 ParseWithKey 解析：
+// H:       字段范围内的某个值(日期字段为 1-28)，如第二段里，H 表示每小时的某一分钟
+// H/n:     从 [0, n) 内的某个值开始每隔 n 执行一次，如第二段里，H/15 表示每 15 分钟
+// H(a-b):  a 到 b 之间的某个值，可以与 /n 组合
+//
+// 日期和星期都有限制时需要同时满足，如需满足其一即可执行(POSIX 语义)请使用 WithDayOr 创建解析器
 //
 // 举例如下:
 //  0/30 * * * * * *                      每 30 秒 执行
 //  0 5,15 5 * * * *　　                   5:5, 05:15 执行
 //  0 0-10 17 * * * *                     17:00 到 17:10 毎隔 1 分钟 执行
 //  0 2 8-20/3 * * * *　　　　　　          8:02,11:02,14:02,17:02,20:02 执行
-func Parse(spec string) (s Scheduler, err error) {
-	// 1.按空格分割字符串获取时间参数
-	params := strings.Fields(spec)
+//  0 0 9 * JAN,JUL MON-FRI              1 月和 7 月的工作日 9:00 执行
+//  0 0 18 L * ?                          每月最后一天 18:00 执行
+//  0 0 9 ? * 2#2                         每月第二个周二 9:00 执行
+//
+// 也支持以下预定义的描述符：
+//  @yearly(或 @annually)                 每年 1 月 1 日 00:00:00 执行，等同于 0 0 0 1 1 *
+//  @monthly                              每月 1 日 00:00:00 执行，等同于 0 0 0 1 * *
+//  @weekly                               每周日 00:00:00 执行，等同于 0 0 0 * * 0
+//  @daily(或 @midnight)                  每天 00:00:00 执行，等同于 0 0 0 * * *
+//  @hourly                               每小时整点执行，等同于 0 0 * * * *
+//  @every <duration>                     从解析时刻起每隔 duration 执行，如 @every 1h30m
+//  @every <duration> from <time>         从 time(RFC3339 格式)起每隔 duration 执行，如 @every 1h from 2019-05-20T00:00:00Z
+//  @at <time>                            在 time(RFC3339 格式)执行一次，如 @at 2019-05-20T09:00:00+08:00
+//
+// 表达式可以用 CRON_TZ= 或 TZ= 开头指定计算执行时间使用的时区，未指定时使用 time.Local，如:
+//  CRON_TZ=Asia/Shanghai 0 0 9 * * *     每天北京时间 9:00 执行
+//
+// 表达式还可以用 CRON_DAY_OR=1(或 0) 开头指定日期和星期是否满足其一即可，优先于 WithDayOr 的设置
+// 以及用 CRON_DST= 开头指定夏令时切换的执行策略，优先于 WithDST 的设置，如:
+//  CRON_TZ=America/New_York CRON_DST=skip,twice 0 30 1,2 * * *
+// 表示 3 月拨快时跳过不存在的 02:30，11 月拨回时 01:30 执行两次
+//
+// Parse 使用秒字段必填、年字段可省略并支持描述符的解析器，如需解析其它格式的表达式请使用 NewParser
+func Parse(spec string) (Scheduler, error) {
+	return defaultParser.Parse(spec)
+}
 
-	if l := len(params); l != 6 {
-		return nil, fmt.Errorf("需要 6 个参数,只传入 %d 个参数", l)
-	}
+// ParseInLocation 与 Parse 相同，但未指定时区前缀时按 loc 时区计算执行时间
+func ParseInLocation(spec string, loc *time.Location) (Scheduler, error) {
+	return defaultParser.ParseInLocation(spec, loc)
+}
+
+// ParseWithKey 与 Parse 相同，表达式中的 H 由 key(如任务名称)确定取值
+// 相同的 key 和表达式总是得到相同的执行时间
+func ParseWithKey(spec, key string) (Scheduler, error) {
+	return defaultParser.ParseWithKey(spec, key)
+}
+
+// descriptors 预定义描述符对应的表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
 
-	ts := new(TimeSchedule)
+// parseDescriptor 解析以 '@' 开头的预定义描述符
+func (p *Parser) parseDescriptor(spec string, tokens []field, loc *time.Location) (Scheduler, error) {
+	name := strings.ToLower(tokens[0].text)
 
-	f := func(str string) (_time uint64, err error) {
-		commas := strings.Split(str, ",")
-		for _, comma := range commas {
-			var _t uint64
-			_t, err = parse(comma)
+	switch name {
+	case "@every":
+		// @every <duration> [from <RFC3339>]
+		start := time.Now()
+		switch {
+		case len(tokens) == 4 && strings.ToLower(tokens[2].text) == "from":
+			t, err := time.Parse(time.RFC3339Nano, tokens[3].text)
 			if err != nil {
-				return
+				return nil, newParseError(spec, tokens[3], -1, "invalid start time")
 			}
-			_time |= _t
+			start = t
+		case len(tokens) != 2:
+			return nil, newParseError(spec, tokens[0], -1, fmt.Sprintf("@every expects 1 duration, got %d", len(tokens)-1))
+		}
+		d, err := time.ParseDuration(tokens[1].text)
+		if err != nil || d <= 0 {
+			return nil, newParseError(spec, tokens[1], -1, "invalid duration")
 		}
+		return &DurationSchedule{start: start, frequency: d}, nil
+	case "@at":
+		if len(tokens) != 2 {
+			return nil, newParseError(spec, tokens[0], -1, fmt.Sprintf("@at expects 1 time, got %d", len(tokens)-1))
+		}
+		t, err := time.Parse(time.RFC3339Nano, tokens[1].text)
+		if err != nil {
+			return nil, newParseError(spec, tokens[1], -1, "invalid time")
+		}
+		return &FixSchedule{rTime: t}, nil
+	}
 
-		return
+	expr, ok := descriptors[name]
+	if !ok {
+		return nil, newParseError(spec, tokens[0], -1, "unknown descriptor")
+	}
+	if len(tokens) != 1 {
+		return nil, newParseError(spec, tokens[1], -1, "unexpected token after descriptor")
 	}
 
-	// 2.解析参数
-	ts.second, err = f(params[0])
-	if err != nil {
-		return
+	// 描述符对应的表达式包含秒字段
+	q := *p
+	q.second, q.year = fieldRequired, fieldOptional
+	return q.ParseInLocation(expr, loc)
+}
+
+// 解析错误原因
+var (
+	errValue = errors.New("invalid value")
+	errRange = errors.New("invalid range")
+	errStep  = errors.New("invalid step")
+)
+
+// bounds 字段的取值范围、别名及偏移量(取值减去偏移量为对应的 bit 位)
+type bounds struct {
+	min, max uint64
+	names    map[string]uint64
+	offset   uint64
+
+	// cycle 循环字段的周期，超出周期的取值按周期折算，如星期的 7 折算为 0
+	cycle uint64
+
+	// lenient 宽松模式，不校验取值范围，超出范围的取值将被忽略
+	lenient bool
+
+	// hash H 使用的散列值，hashed 为 false 时不支持 H
+	// hashMax 不指定范围时 H 的最大值，为 0 时使用字段的最大值
+	hash    uint64
+	hashed  bool
+	hashMax uint64
+}
+
+// 各字段的取值范围
+var (
+	seconds  = bounds{min: 0, max: 59, cycle: 60}
+	minutes  = bounds{min: 0, max: 59, cycle: 60}
+	hours    = bounds{min: 0, max: 23, cycle: 24}
+	days     = bounds{min: 1, max: 31, cycle: 31, hashMax: 28}
+	months   = bounds{min: 1, max: 12, cycle: 12, names: monthNames}
+	weekDays = bounds{min: 0, max: 7, cycle: 7, names: weekNames}
+	years    = bounds{min: StartYear, max: StartYear + 63, offset: StartYear}
+)
+
+var monthNames = map[string]uint64{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekNames = map[string]uint64{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// value 解析单个取值，支持数字和字段别名(不区分大小写)
+func (b bounds) value(s string) (uint64, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || v < b.offset {
+		return 0, errValue
 	}
+	if !b.lenient && (v < b.min || v > b.max) {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, b.min, b.max)
+	}
+	return v, nil
+}
 
-	ts.min, err = f(params[1])
-	if err != nil {
-		return
+// last 字段一个周期内的最大值，如星期为 6
+func (b bounds) last() uint64 {
+	if b.cycle > 0 {
+		return b.min + b.cycle - 1
 	}
+	return b.max
+}
 
-	ts.hour, err = f(params[2])
-	if err != nil {
-		return
+// fold 将超出周期的取值折算到周期内
+func (b bounds) fold(v uint64) uint64 {
+	if b.cycle > 0 && v >= b.min+b.cycle {
+		return b.min + (v-b.min)%b.cycle
 	}
+	return v
+}
 
-	ts.day, err = f(params[3])
-	if err != nil {
-		return
+// span 解析 '/' 之前的字符串，返回取值范围
+// step 表示是否包含 '/'
+func (b bounds) span(expr string, step bool) (start, end uint64, err error) {
+	hyphen := strings.Split(expr, "-")
+	switch len(hyphen) {
+	case 1:
+		if hyphen[0] == "*" {
+			return b.min, b.last(), nil
+		}
+		start, err = b.value(hyphen[0])
+		if err != nil {
+			return
+		}
+		end = start
+
+		// N/step 表示从 N 开始到字段最大值每隔 step 执行
+		if step && start <= b.last() {
+			end = b.last()
+		}
+	case 2:
+		start, err = b.value(hyphen[0])
+		if err != nil {
+			return
+		}
+		end, err = b.value(hyphen[1])
+	default:
+		err = errRange
 	}
+	return
+}
 
-	ts.month, err = f(params[4])
-	if err != nil {
+// hashSpan 解析 H、H(a-b) 格式的字符串，根据 hash 确定取值范围
+// step 表示是否包含 '/'，包含时起始值在 [a, a+frequency) 内，否则起始值与结束值相同且在 [a, b] 内
+// 返回的间隔不大于取值范围的长度
+func (b bounds) hashSpan(expr string, frequency uint64, step bool) (start, end, freq uint64, err error) {
+	if !b.hashed {
+		err = errors.New("H requires a hash key")
 		return
 	}
-
-	ts.weekDay, err = f(params[5])
-	if err != nil {
+	if b.cycle == 0 {
+		err = errors.New("H is not supported")
 		return
 	}
 
-	ts.loc = time.Local
-
-	// 3.修正不合法数据
-	ts.amend()
+	lo, hi := b.min, b.last()
+	if b.hashMax > 0 {
+		hi = b.hashMax
+	}
+	switch {
+	case expr == "H":
+	case strings.HasPrefix(expr, "H(") && strings.HasSuffix(expr, ")"):
+		hyphen := strings.Split(expr[2:len(expr)-1], "-")
+		if len(hyphen) != 2 {
+			err = errRange
+			return
+		}
+		if lo, err = b.value(hyphen[0]); err != nil {
+			return
+		}
+		if hi, err = b.value(hyphen[1]); err != nil {
+			return
+		}
+		if lo > hi {
+			err = errRange
+			return
+		}
+	default:
+		err = errValue
+		return
+	}
 
-	s = ts
-	return
+	if step {
+		if n := hi - lo + 1; frequency > n {
+			frequency = n
+		}
+		return lo + b.hash%frequency, hi, frequency, nil
+	}
+	start = lo + b.hash%(hi-lo+1)
+	return start, start, frequency, nil
 }
 
 // parse 解析如下格式字符串:
-// number | number "-" number [ "/" number ] | *[ "/" number]
-func parse(expr string) (_time uint64, err error) {
+// value | value "-" value [ "/" number ] | *[ "/" number] | H [ "(" value "-" value ")" ] [ "/" number ]
+// value: number | name
+func parse(expr string, b bounds) (_time uint64, err error) {
 	var (
 		frequency  = uint64(0)
 		start, end = uint64(0), uint64(0)
@@ -111,55 +327,120 @@ func parse(expr string) (_time uint64, err error) {
 		frequency = 1
 	case 2:
 		frequency, err = strconv.ParseUint(slash[1], 10, 64)
-		if err != nil || frequency < 0 {
-			err = ErrInvialParam
+		if err != nil || frequency == 0 {
+			err = errStep
 			return
 		}
 	default:
-		err = ErrInvialParam
+		err = errStep
 		return
 	}
 
 	// 解析 '/' 之前的字符串
-	hyphen := strings.Split(slash[0], "-")
-	switch len(hyphen) {
-	case 1:
-		if hyphen[0] == "*" {
-			start, end = 0, 63
-			break
-		}
-		start, err = strconv.ParseUint(hyphen[0], 10, 64)
-		if err != nil {
-			err = ErrInvialParam
-			return
-		}
-		end = start
-	case 2:
-		start, err = strconv.ParseUint(hyphen[0], 10, 64)
-		if err != nil {
-			err = ErrInvialParam
-			return
-		}
-		end, err = strconv.ParseUint(hyphen[1], 10, 64)
-		if err != nil {
-			err = ErrInvialParam
-			return
-		}
-	default:
-		err = ErrInvialParam
+	if strings.HasPrefix(slash[0], "H") {
+		start, end, frequency, err = b.hashSpan(slash[0], frequency, len(slash) == 2)
+	} else {
+		start, end, err = b.span(slash[0], len(slash) == 2)
+	}
+	if err != nil {
 		return
 	}
 
-	if start > 63 {
-		start = 64
+	// 结束值小于起始值时跨越周期，如小时字段 22-2 表示 22、23、0、1、2 点
+	wrap := false
+	if start > end {
+		if b.cycle == 0 {
+			err = errRange
+			return
+		}
+		end += b.cycle
+		wrap = true
 	}
-	if end > 63 {
-		end = 63
+	if b.lenient && !wrap && end > b.offset+63 {
+		end = b.offset + 63
 	}
 
-	for i := start; i < end+1; i += frequency {
-		_time = bitSet(_time, i, 1)
+	// 取值范围内的别名(如星期的 7)总是折算，宽松模式下超出范围的取值不折算，之后被忽略
+	for i := start; i <= end; i += frequency {
+		v := i
+		if wrap || i <= b.max {
+			v = b.fold(i)
+		}
+		if v >= b.offset && v-b.offset <= 63 {
+			_time = bitSet(_time, v-b.offset, 1)
+		}
+
+		// 间隔很大时 i += frequency 会溢出
+		if frequency > end-i {
+			break
+		}
 	}
 
 	return
 }
+
+// parseDaySpecial 解析日期字段的特殊符号: ?、L、L-n、LW、nW
+// 返回 ok 为 true 表示已处理，_time 为需要设置的日期 bit 位
+func (t *TimeSchedule) parseDaySpecial(expr string) (_time uint64, ok bool, err error) {
+	upper := strings.ToUpper(expr)
+	switch {
+	case upper == "?":
+		_time = RangeDay
+	case upper == "L":
+		t.lastDay |= 1
+	case upper == "LW":
+		t.lastWeekday = true
+	case strings.HasPrefix(upper, "L-"):
+		n, err := strconv.ParseUint(upper[2:], 10, 64)
+		if err != nil || n > 30 {
+			return 0, false, errValue
+		}
+		t.lastDay = bitSet(t.lastDay, n, 1)
+	case strings.HasSuffix(upper, "W"):
+		n, err := strconv.ParseUint(upper[:len(upper)-1], 10, 64)
+		if err != nil || n < 1 || n > 31 {
+			return 0, false, errValue
+		}
+		t.nearestWeekday = bitSet(t.nearestWeekday, n, 1)
+	default:
+		return 0, false, nil
+	}
+	return _time, true, nil
+}
+
+// parseWeekDaySpecial 解析星期字段的特殊符号: ?、L、nL、n#k，b 为星期的取值范围
+// 返回 ok 为 true 表示已处理，_time 为需要设置的星期 bit 位
+func (t *TimeSchedule) parseWeekDaySpecial(expr string, b bounds) (_time uint64, ok bool, err error) {
+	upper := strings.ToUpper(expr)
+	switch {
+	case upper == "?":
+		_time = RangeWeekDay
+	case upper == "L":
+		_time = 1 << uint64(time.Saturday)
+	case strings.Contains(upper, "#"):
+		parts := strings.Split(upper, "#")
+		if len(parts) != 2 {
+			return 0, false, errValue
+		}
+		n, err := b.value(parts[0])
+		if err != nil || n > b.max {
+			return 0, false, errValue
+		}
+		n = b.fold(n) - b.offset
+		k, err := strconv.ParseUint(parts[1], 10, 8)
+		if err != nil || k < 1 || k > 5 {
+			return 0, false, errValue
+		}
+		t.nthWeekDay[n] |= 1 << k
+	case len(upper) > 1 && strings.HasSuffix(upper, "L"):
+		n, err := b.value(upper[:len(upper)-1])
+		if err != nil || n > b.max {
+			return 0, false, errValue
+		}
+		n = b.fold(n) - b.offset
+		t.lastWeekDay = bitSet(t.lastWeekDay, n, 1)
+	default:
+		return 0, false, nil
+	}
+	return _time, true, nil
+}
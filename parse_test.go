@@ -1,6 +1,7 @@
 package corn
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -21,11 +22,11 @@ func Test_Parse(t *testing.T) {
 				expr string
 				want *TimeSchedule
 			}{
-				{"* 4 15 2 1 * ", &TimeSchedule{last{}, 0xFFFFFFFFFFFFFFF, 0x10, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 * 15 2 1 * ", &TimeSchedule{last{}, 0x20, 0xFFFFFFFFFFFFFFF, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 * 2 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0xFFFFFF, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 15 * 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0xFFFFFFFE, 0x2, 0x7F, time.Local}},
-				{"5 4 15 2 * * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x1FFE, 0x7F, time.Local}},
+				{"* 4 15 2 1 * ", &TimeSchedule{second: 0xFFFFFFFFFFFFFFF, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 * 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0xFFFFFFFFFFFFFFF, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 * 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0xFFFFFF, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 * 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0xFFFFFFFE, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 * * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
 			},
 		},
 
@@ -36,11 +37,11 @@ func Test_Parse(t *testing.T) {
 				expr string
 				want *TimeSchedule
 			}{
-				{"5,6 4 15 2 1 * ", &TimeSchedule{last{}, 0x60, 0x10, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4,5,24 15 2 1 * ", &TimeSchedule{last{}, 0x20, 0x1000030, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 15,22 2 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x408000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 15 2,12 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x1004, 0x2, 0x7F, time.Local}},
-				{"5 4 15 2 1,5 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x22, 0x7F, time.Local}},
+				{"5,6 4 15 2 1 * ", &TimeSchedule{second: 0x60, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4,5,24 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0x1000030, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15,22 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x408000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2,12 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x1004, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1,5 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x22, weekDay: 0x7F, loc: time.Local}},
 			},
 		},
 
@@ -51,12 +52,12 @@ func Test_Parse(t *testing.T) {
 				expr string
 				want *TimeSchedule
 			}{
-				{"0-59 4 15 2 1 * ", &TimeSchedule{last{}, 0xFFFFFFFFFFFFFFF, 0x10, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 0-59 15 2 1 * ", &TimeSchedule{last{}, 0x20, 0xFFFFFFFFFFFFFFF, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 0-23 2 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0xFFFFFF, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 15 1-31 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0xFFFFFFFE, 0x2, 0x7F, time.Local}},
-				{"5 4 15 2 1-12 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x1FFE, 0x7F, time.Local}},
-				{"5 4 15 2 1 0-6 ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
+				{"0-59 4 15 2 1 * ", &TimeSchedule{second: 0xFFFFFFFFFFFFFFF, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 0-59 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0xFFFFFFFFFFFFFFF, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 0-23 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0xFFFFFF, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 1-31 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0xFFFFFFFE, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1-12 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1 0-6 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
 			},
 		},
 
@@ -67,12 +68,49 @@ func Test_Parse(t *testing.T) {
 				expr string
 				want *TimeSchedule
 			}{
-				{"*/4 4 15 2 1 * ", &TimeSchedule{last{}, 0x111111111111111, 0x10, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 */4 15 2 1 * ", &TimeSchedule{last{}, 0x20, 0x111111111111111, 0x8000, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 */4 2 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x111111, 0x4, 0x2, 0x7F, time.Local}},
-				{"5 4 15 */4 1 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x11111110, 0x2, 0x7F, time.Local}},
-				{"5 4 15 2 */4 * ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x1110, 0x7F, time.Local}},
-				{"5 4 15 2 1 */3 ", &TimeSchedule{last{}, 0x20, 0x10, 0x8000, 0x4, 0x2, 0x49, time.Local}},
+				{"*/4 4 15 2 1 * ", &TimeSchedule{second: 0x111111111111111, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 */4 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0x111111111111111, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 */4 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x111111, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 */4 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x22222222, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 */4 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x222, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1 */3 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x49, loc: time.Local}},
+			},
+		},
+
+		// 'm/n' 测试
+		{
+			"'m/n' 测试",
+			[]struct {
+				expr string
+				want *TimeSchedule
+			}{
+				{"0/30 4 15 2 1 * ", &TimeSchedule{second: 0x40000001, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 10/20 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0x4000040000400, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 2/8 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x40404, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 */10 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x80200802, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 JUN/3 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x1240, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1 1/2 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x2A, loc: time.Local}},
+				{"5 4 15 2 1 * 2080/2", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: 0xA000000000000000, loc: time.Local}},
+			},
+		},
+
+		// 跨周期范围
+		{
+			"跨周期范围测试",
+			[]struct {
+				expr string
+				want *TimeSchedule
+			}{
+				{"50-5 4 15 2 1 * ", &TimeSchedule{second: 0xFFC00000000003F, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 58-1/2 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0x400000000000001, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 22-2 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0xC00007, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 22-2/2 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x400005, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 30-2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0xC0000006, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 NOV-FEB * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x1806, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1 FRI-MON ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x63, loc: time.Local}},
+				{"5 4 15 2 1 5-1/2 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x21, loc: time.Local}},
+				{"5 4 15 2 1 6-7 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x41, loc: time.Local}},
+				{"5 4 15 2 1 7-1 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x3, loc: time.Local}},
 			},
 		},
 
@@ -83,26 +121,41 @@ func Test_Parse(t *testing.T) {
 				expr string
 				want *TimeSchedule
 			}{
-				{"0-12/4 * * * * * ", &TimeSchedule{last{}, 0x1111, 0xFFFFFFFFFFFFFFF, 0xFFFFFF, 0xFFFFFFFE, 0x1FFE, 0x7F, time.Local}},
-				{"0-12/4,20-30/5 * * * * * ", &TimeSchedule{last{}, 0x42101111, 0xFFFFFFFFFFFFFFF, 0xFFFFFF, 0xFFFFFFFE, 0x1FFE, 0x7F, time.Local}},
+				{"0-12/4 * * * * * ", &TimeSchedule{second: 0x1111, min: 0xFFFFFFFFFFFFFFF, hour: 0xFFFFFF, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
+				{"0-12/4,20-30/5 * * * * * ", &TimeSchedule{second: 0x42101111, min: 0xFFFFFFFFFFFFFFF, hour: 0xFFFFFF, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
 				// ','分割范围交叉覆盖
-				{"0-12/4,20-30/5,25-45/3 * * * * * ", &TimeSchedule{last{}, 0x924D2101111, 0xFFFFFFFFFFFFFFF, 0xFFFFFF, 0xFFFFFFFE, 0x1FFE, 0x7F, time.Local}},
+				{"0-12/4,20-30/5,25-45/3 * * * * * ", &TimeSchedule{second: 0x924D2101111, min: 0xFFFFFFFFFFFFFFF, hour: 0xFFFFFF, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
+			},
+		},
+
+		// 月份、星期别名
+		{
+			"月份、星期别名测试",
+			[]struct {
+				expr string
+				want *TimeSchedule
+			}{
+				{"5 4 15 2 JAN * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 jan,Jul * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x82, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 MAR-may * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x38, weekDay: 0x7F, loc: time.Local}},
+				{"5 4 15 2 1 MON-FRI ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x3E, loc: time.Local}},
+				{"5 4 15 2 1 sun,SAT ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x41, loc: time.Local}},
+				{"5 4 15 2 1 MON-6/2 ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x2A, loc: time.Local}},
 			},
 		},
 
-		// 非常规字符串
+		// 年份
 		{
-			"容错恢复测试",
+			"年份测试",
 			[]struct {
 				expr string
 				want *TimeSchedule
 			}{
-				// 取值溢出
-				{"0-62 0-60 0-24 0-32 0-63 0-63 ", &TimeSchedule{last{}, 0xFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFF, 0xFFFFFF, 0xFFFFFFFE, 0x1FFE, 0x7F, time.Local}},
-				// 无效取值
-				{"60-63 60-63 24-63 32-63 13-63 7-63 ", &TimeSchedule{last{}, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, time.Local}},
-				// 不合法参数
-				{"64 64 64 64 64 64 ", &TimeSchedule{last{}, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, time.Local}},
+				{"5 4 15 2 1 * ", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: RangeYear, loc: time.Local}},
+				{"5 4 15 2 1 * *", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: RangeYear, loc: time.Local}},
+				{"5 4 15 2 1 * 2019", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: 0x1, loc: time.Local}},
+				{"5 4 15 2 1 * 2027-2029", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: 0x700, loc: time.Local}},
+				{"5 4 15 2 1 * 2020,2082", &TimeSchedule{second: 0x20, min: 0x10, hour: 0x8000, day: 0x4, month: 0x2, weekDay: 0x7F, year: 0x8000000000000002, loc: time.Local}},
 			},
 		},
 	}
@@ -118,7 +171,8 @@ func Test_Parse(t *testing.T) {
 				switch _ts := ts.(type) {
 				case *TimeSchedule:
 					if _ts.second != val.want.second || _ts.min != val.want.min || _ts.hour != val.want.hour ||
-						_ts.day != val.want.day || _ts.month != val.want.month {
+						_ts.day != val.want.day || _ts.month != val.want.month || _ts.weekDay != val.want.weekDay ||
+						(val.want.year != 0 && _ts.year != val.want.year) {
 						t.Errorf("expr: %s, get: %+v, want: %+v", val.expr, _ts, val.want)
 					}
 				default:
@@ -130,3 +184,334 @@ func Test_Parse(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseInvalidName(t *testing.T) {
+	data := []string{
+		"0 0 0 * JANUARY * ",
+		"0 0 0 * MON * ",
+		"0 0 0 * * JAN ",
+		"0 0 0 MON * * ",
+		"0 0 0 * * MON-XYZ ",
+		"0 0 0 * * * 2018",
+		"0 0 0 * * * JAN",
+		"0 0 0 * * * * *",
+		"? 0 0 * * * ",
+		"0 0 0 W * * ",
+		"0 0 0 32W * * ",
+		"0 0 0 0W * * ",
+		"0 0 0 L-31 * * ",
+		"0 0 0 * * 2#6 ",
+		"0 0 0 * * 2#0 ",
+		"0 0 0 * * 8#1 ",
+		"0 0 0 * * 2#1#2 ",
+		"0 0 0 * * XL ",
+		"0 0 0 * * LW ",
+	}
+
+	for _, expr := range data {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expr: %s, 期望返回错误", expr)
+		}
+	}
+}
+
+func Test_ParseDescriptor(t *testing.T) {
+	data := []struct {
+		expr string
+		now  time.Time
+		next time.Time
+	}{
+		{"@yearly", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"@annually", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"@monthly", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local)},
+		{"@weekly", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 26, 0, 0, 0, 0, time.Local)},
+		{"@daily", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 21, 0, 0, 0, 0, time.Local)},
+		{"@midnight", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 21, 0, 0, 0, 0, time.Local)},
+		{"@hourly", time.Date(2019, 5, 20, 0, 30, 0, 0, time.Local), time.Date(2019, 5, 20, 1, 0, 0, 0, time.Local)},
+		{" @HOURLY ", time.Date(2019, 5, 20, 0, 30, 0, 0, time.Local), time.Date(2019, 5, 20, 1, 0, 0, 0, time.Local)},
+	}
+
+	for _, val := range data {
+		ts, err := Parse(val.expr)
+		if err != nil {
+			t.Errorf("expr: %s, err: %v", val.expr, err)
+			continue
+		}
+		if get := ts.Next(val.now); !get.Equal(val.next) {
+			t.Errorf("expr: %s, want: %s, get: %s", val.expr, val.next, get)
+		}
+	}
+
+	for _, expr := range []string{"@", "@never", "@daily 1", "@every", "@every 1x", "@every -1h", "@every 1h 2h"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expr: %s, 期望返回错误", expr)
+		}
+	}
+}
+
+func Test_ParseEvery(t *testing.T) {
+	before := time.Now()
+	s, err := Parse("@every 1h30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, ok := s.(*DurationSchedule)
+	if !ok {
+		t.Fatalf("调度器类型错误: %T", s)
+	}
+	if ds.frequency != 90*time.Minute || ds.start.Before(before) || ds.start.After(time.Now()) {
+		t.Errorf("get: %+v", ds)
+	}
+	if get := ds.Next(ds.start); !get.Equal(ds.start.Add(90 * time.Minute)) {
+		t.Errorf("want: %s, get: %s", ds.start.Add(90*time.Minute), get)
+	}
+}
+
+func Test_ParseLenient(t *testing.T) {
+	parser := NewParser(WithSeconds(), WithYearOptional(), WithLenient())
+	data := []struct {
+		expr string
+		want *TimeSchedule
+	}{
+		// 取值溢出
+		{"0-62 0-60 0-24 0-32 0-63 0-63 ", &TimeSchedule{second: 0xFFFFFFFFFFFFFFF, min: 0xFFFFFFFFFFFFFFF, hour: 0xFFFFFF, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x7F, loc: time.Local}},
+		// 无效取值，星期的 7 表示周日
+		{"60-63 60-63 24-63 32-63 13-63 7-63 ", &TimeSchedule{second: 0x0, min: 0x0, hour: 0x0, day: 0x0, month: 0x0, weekDay: 0x1, loc: time.Local}},
+		{"0 0 0 * * 7", &TimeSchedule{second: 0x1, min: 0x1, hour: 0x1, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x1, loc: time.Local}},
+		{"0 0 0 * * 5-7", &TimeSchedule{second: 0x1, min: 0x1, hour: 0x1, day: 0xFFFFFFFE, month: 0x1FFE, weekDay: 0x61, loc: time.Local}},
+		// 不合法参数
+		{"64 64 64 64 64 64 ", &TimeSchedule{second: 0x0, min: 0x0, hour: 0x0, day: 0x0, month: 0x0, weekDay: 0x0, loc: time.Local}},
+	}
+
+	for _, val := range data {
+		s, err := parser.Parse(val.expr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		_ts := s.(*TimeSchedule)
+		if _ts.second != val.want.second || _ts.min != val.want.min || _ts.hour != val.want.hour ||
+			_ts.day != val.want.day || _ts.month != val.want.month || _ts.weekDay != val.want.weekDay {
+			t.Errorf("expr: %s, get: %+v, want: %+v", val.expr, _ts, val.want)
+		}
+	}
+
+	s, err := parser.Parse("CRON_TZ=UTC 0 0 0 * * 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2019, 5, 18, 0, 0, 0, 0, time.UTC)
+	if get, want := s.Next(from), time.Date(2019, 5, 19, 0, 0, 0, 0, time.UTC); !get.Equal(want) {
+		t.Errorf("周日 want: %s, get: %s", want, get)
+	}
+}
+
+func Test_ParseHugeStep(t *testing.T) {
+	// 间隔接近 uint64 上限时不应溢出回绕
+	s, err := Parse("1/18446744073709551615 5-10/18446744073709551615 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := s.(*TimeSchedule); ts.second != 0x2 || ts.min != 0x20 {
+		t.Errorf("want: second 0x2 min 0x20, get: second %#x min %#x", ts.second, ts.min)
+	}
+
+	s, err = ParseWithKey("H/18446744073709551615 * * * * *", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := s.(*TimeSchedule); bitCount(ts.second) != 1 {
+		t.Errorf("want: 1 个秒, get: %#x", ts.second)
+	}
+
+	s, err = NewParser(WithSeconds(), WithLenient()).Parse("0 0 0 * * 5-3/18446744073709551614")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := s.(*TimeSchedule); ts.weekDay != 0x20 {
+		t.Errorf("want: weekDay 0x20, get: %#x", ts.weekDay)
+	}
+}
+
+func Test_ParseOutOfRange(t *testing.T) {
+	data := []struct {
+		expr   string
+		name   string
+		reason string
+	}{
+		{"60 0 0 * * *", "second", "value 60 out of range 0-59"},
+		{"0 70 * * * *", "minute", "value 70 out of range 0-59"},
+		{"0 0 25 * * *", "hour", "value 25 out of range 0-23"},
+		{"0 0 0 0 * *", "day", "value 0 out of range 1-31"},
+		{"0 0 0 32 * *", "day", "value 32 out of range 1-31"},
+		{"0 0 0 * 0 *", "month", "value 0 out of range 1-12"},
+		{"0 0 0 * 1-13 *", "month", "value 13 out of range 1-12"},
+		{"0 0 0 * * 8", "weekday", "value 8 out of range 0-7"},
+		{"0 0 0 * * * 2083", "year", "value 2083 out of range 2019-2082"},
+		{"0 0 0 * * * 2030-2020", "year", "invalid range"},
+	}
+
+	for _, val := range data {
+		_, err := Parse(val.expr)
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("expr: %s, err: %v, 期望为 *ParseError", val.expr, err)
+			continue
+		}
+		if pe.Name != val.name || pe.Reason != val.reason {
+			t.Errorf("expr: %s, get: %s %s, want: %s %s", val.expr, pe.Name, pe.Reason, val.name, val.reason)
+		}
+	}
+}
+
+func Test_ParseSunday(t *testing.T) {
+	for _, expr := range []string{"0 0 0 * * 7", "0 0 0 * * 0", "0 0 0 * * SUN"} {
+		s, err := Parse(expr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if w := s.(*TimeSchedule).weekDay; w != 0x1 {
+			t.Errorf("expr: %s, weekDay: %x", expr, w)
+		}
+	}
+
+	s, err := Parse("0 0 0 * * 5-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := s.(*TimeSchedule).weekDay; w != 0x61 {
+		t.Errorf("weekDay: %x", w)
+	}
+}
+
+func Test_ParseLocation(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	now := time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		next time.Time
+	}{
+		{"CRON_TZ 前缀", "CRON_TZ=Asia/Shanghai 0 0 9 * * *", nil, time.Date(2019, 5, 20, 1, 0, 0, 0, time.UTC)},
+		{"TZ 前缀", "TZ=America/New_York 0 0 9 * * *", nil, time.Date(2019, 5, 20, 13, 0, 0, 0, time.UTC)},
+		{"指定时区", "0 0 9 * * *", shanghai, time.Date(2019, 5, 20, 1, 0, 0, 0, time.UTC)},
+		{"前缀优先", "TZ=America/New_York 0 0 9 * * *", shanghai, time.Date(2019, 5, 20, 13, 0, 0, 0, time.UTC)},
+		{"UTC", "TZ=UTC 0 0 9 * * *", newYork, time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC)},
+		{"描述符", "CRON_TZ=Asia/Shanghai @daily", nil, time.Date(2019, 5, 20, 16, 0, 0, 0, time.UTC)},
+		{"描述符指定时区", "@daily", newYork, time.Date(2019, 5, 20, 4, 0, 0, 0, time.UTC)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			var (
+				s   Scheduler
+				err error
+			)
+			if p.loc == nil {
+				s, err = Parse(p.expr)
+			} else {
+				s, err = ParseInLocation(p.expr, p.loc)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			get := s.Next(now)
+			if !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+			if get.Location() != time.UTC {
+				t.Errorf("返回时间的时区: %s, 期望为 UTC", get.Location())
+			}
+		})
+	}
+
+	_, err = Parse("CRON_TZ=Mars/Olympus 0 0 9 * * *")
+	if pe, ok := err.(*ParseError); !ok || pe.Token != "CRON_TZ=Mars/Olympus" || pe.Reason != "unknown time zone" {
+		t.Errorf("err: %v", err)
+	}
+
+	_, err = Parse("TZ=UTC 0 x 9 * * *")
+	if pe, ok := err.(*ParseError); !ok || pe.Field != 1 || pe.Offset != 9 {
+		t.Errorf("err: %v", err)
+	}
+}
+
+func Test_ParseWithKey(t *testing.T) {
+	data := []struct {
+		expr  string
+		check func(ts *TimeSchedule) bool
+	}{
+		{"0 H * * * *", func(ts *TimeSchedule) bool { return bitCount(ts.min) == 1 && ts.min&RangeMin == ts.min }},
+		{"0 H/15 * * * *", func(ts *TimeSchedule) bool {
+			m := findBit(ts.min, 0, 59)
+			return m < 15 && ts.min == (0x1|0x1<<15|0x1<<30|0x1<<45)<<m
+		}},
+		{"0 H(0-29) * * * *", func(ts *TimeSchedule) bool { return bitCount(ts.min) == 1 && findBit(ts.min, 0, 59) <= 29 }},
+		{"0 0 H(8-10)/2 * * *", func(ts *TimeSchedule) bool { return ts.hour == 0x500 || ts.hour == 0x200 }},
+		{"0 0 0 H * *", func(ts *TimeSchedule) bool { return bitCount(ts.day) == 1 && findBit(ts.day, 1, 31) <= 28 }},
+		{"0 0 0 * * H", func(ts *TimeSchedule) bool { return bitCount(ts.weekDay) == 1 && ts.weekDay&RangeWeekDay == ts.weekDay }},
+		{"0 0 H(1-2)/10 * * *", func(ts *TimeSchedule) bool { return ts.hour == 0x2 || ts.hour == 0x4 }},
+	}
+
+	for _, val := range data {
+		for _, key := range []string{"", "job-a", "job-b", "报表任务"} {
+			s, err := ParseWithKey(val.expr, key)
+			if err != nil {
+				t.Errorf("expr: %s, err: %v", val.expr, err)
+				continue
+			}
+			if ts := s.(*TimeSchedule); !val.check(ts) {
+				t.Errorf("expr: %s, key: %s, get: %v", val.expr, key, ts)
+			}
+
+			// 相同的 key 结果相同
+			again, _ := ParseWithKey(val.expr, key)
+			if s.(*TimeSchedule).String() != again.(*TimeSchedule).String() {
+				t.Errorf("expr: %s, key: %s, 两次解析结果不同", val.expr, key)
+			}
+		}
+	}
+
+	// 大量 key 应分散到不同的分钟
+	minutes := make(map[uint64]bool)
+	for i := 0; i < 100; i++ {
+		s, err := ParseWithKey("0 H * * * *", fmt.Sprintf("job-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		minutes[s.(*TimeSchedule).min] = true
+	}
+	if len(minutes) < 30 {
+		t.Errorf("100 个 key 只分散到 %d 个分钟", len(minutes))
+	}
+
+	for _, expr := range []string{"0 H(5-1) * * * *", "0 H(0-60) * * * *", "0 H(1) * * * *", "0 Hx * * * *", "0 0 0 * * * H"} {
+		if _, err := ParseWithKey(expr, "job"); err == nil {
+			t.Errorf("expr: %s, 期望返回错误", expr)
+		}
+	}
+	if _, err := Parse("0 H * * * *"); err == nil {
+		t.Error("未指定 key 时期望返回错误")
+	}
+}
+
+func bitCount(n uint64) int {
+	c := 0
+	for ; n > 0; n &= n - 1 {
+		c++
+	}
+	return c
+}
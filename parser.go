@@ -0,0 +1,463 @@
+package corn
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// fieldMode 可选字段的解析模式
+type fieldMode int
+
+const (
+	fieldNone     fieldMode = iota // 不包含该字段
+	fieldOptional                  // 可以省略该字段
+	fieldRequired                  // 必须包含该字段
+)
+
+// Parser 表达式解析器，通过 ParserOption 定制支持的表达式格式
+// 默认为 5 个字段的 linux crontab 格式: 分 时 日 月 星期
+type Parser struct {
+	// 秒字段(第一个字段)，省略时为 0
+	second fieldMode
+
+	// 年字段(最后一个字段)，省略时为 *
+	year fieldMode
+
+	// 是否支持 @daily 等预定义描述符
+	descriptor bool
+
+	// 宽松模式，不校验取值范围
+	lenient bool
+
+	// 是否支持 AWS EventBridge 的 cron()、rate() 表达式
+	aws bool
+
+	// 日期和星期都有限制时，满足其一即可
+	dayOr bool
+
+	// 夏令时切换时的执行策略
+	dst DSTPolicy
+
+	// H 使用的散列 key，hashed 为 false 时不支持 H
+	key    string
+	hashed bool
+}
+
+// ParserOption 定制 Parser 支持的表达式格式
+type ParserOption func(p *Parser)
+
+// WithSeconds 表达式必须包含秒字段
+func WithSeconds() ParserOption {
+	return func(p *Parser) {
+		p.second = fieldRequired
+	}
+}
+
+// WithSecondsOptional 表达式可以包含秒字段
+// 字段数量有歧义时优先视为秒字段
+func WithSecondsOptional() ParserOption {
+	return func(p *Parser) {
+		p.second = fieldOptional
+	}
+}
+
+// WithYear 表达式必须包含年字段
+func WithYear() ParserOption {
+	return func(p *Parser) {
+		p.year = fieldRequired
+	}
+}
+
+// WithYearOptional 表达式可以包含年字段
+func WithYearOptional() ParserOption {
+	return func(p *Parser) {
+		p.year = fieldOptional
+	}
+}
+
+// WithDescriptors 支持 @daily、@every 1h 等预定义描述符
+func WithDescriptors() ParserOption {
+	return func(p *Parser) {
+		p.descriptor = true
+	}
+}
+
+// WithLenient 宽松模式，不校验取值范围，超出范围的取值将被忽略
+// 如 0 0 25 * * * 可以解析成功但永远不会执行
+func WithLenient() ParserOption {
+	return func(p *Parser) {
+		p.lenient = true
+	}
+}
+
+// WithDayOr 日期和星期字段都有限制(都不是 * 或 ?)时，满足其中一个即可执行(POSIX/Vixie cron 的语义)
+// 默认需要同时满足，如 0 0 0 1,15 * 1 默认表示 1 日和 15 日恰好是周一时执行，
+// 使用 WithDayOr 后表示每月 1 日、15 日以及每个周一执行
+func WithDayOr() ParserOption {
+	return func(p *Parser) {
+		p.dayOr = true
+	}
+}
+
+// WithDST 指定时区切换夏令时的执行策略，默认为 DSTPolicy{DSTShift, DSTOnce}，如:
+//  NewParser(WithSeconds(), WithDST(DSTPolicy{Gap: DSTSkip, Overlap: DSTOnce}))
+// 时钟拨快时跳过不存在的时间，时钟拨回时重复的时间只执行一次
+func WithDST(policy DSTPolicy) ParserOption {
+	return func(p *Parser) {
+		p.dst = policy
+	}
+}
+
+// WithAWS 支持 AWS EventBridge 的 cron()、rate() 表达式，其它表达式按解析器的配置解析:
+//  cron(分 时 日 月 星期 年)             如 cron(0 12 * * ? *)，日期和星期必须有一个是 ?
+//  rate(n 单位)                          单位为 minute(s)、hour(s)、day(s)，如 rate(5 minutes)
+// cron() 的星期为 1-7(SUN=1)，同样支持 SUN-SAT 及 L、nL、n#k，日期支持 L、LW、nW
+// 没有时区前缀(CRON_TZ=)时按 UTC 时间计算，与 EventBridge 相同；cron() 同样支持 CRON_DST= 前缀和 WithDST
+// rate() 从解析时刻起每隔指定时间执行
+func WithAWS() ParserOption {
+	return func(p *Parser) {
+		p.aws = true
+	}
+}
+
+// NewParser 根据 opts 创建解析器
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// defaultParser 包级别 Parse 使用的解析器: 秒 分 时 日 月 星期 [年]
+var defaultParser = NewParser(WithSeconds(), WithYearOptional(), WithDescriptors())
+
+// Parse 解析表达式，字段含义及支持的特殊符号参见包级别的 Parse
+// 解析失败时返回 *ParseError
+func (p *Parser) Parse(spec string) (Scheduler, error) {
+	return p.ParseInLocation(spec, time.Local)
+}
+
+// ParseWithKey 解析表达式，表达式中的 H 由 key 确定取值
+func (p *Parser) ParseWithKey(spec, key string) (Scheduler, error) {
+	q := *p
+	q.key, q.hashed = key, true
+	return q.ParseInLocation(spec, time.Local)
+}
+
+// ParseInLocation 解析表达式，按 loc 时区计算执行时间
+// 表达式以 CRON_TZ= 或 TZ= 开头时使用其指定的时区
+func (p *Parser) ParseInLocation(spec string, loc *time.Location) (Scheduler, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	tokens, tz, dayOr, dst, err := p.parsePrefixes(spec, splitFields(spec))
+	if err != nil {
+		return nil, err
+	}
+
+	if p.aws && isAWSExpr(tokens) {
+		// EventBridge 的表达式总是使用 UTC 时间
+		if tz == nil {
+			tz = time.UTC
+		}
+		// cron() 的日期和星期必须有一个为 ?，因此 CRON_DAY_OR 不影响执行时间
+		s, err := p.parseAWS(spec, tokens, tz)
+		if ts, ok := s.(*TimeSchedule); ok {
+			ts.dst = dst
+		}
+		return s, err
+	}
+	if tz != nil {
+		loc = tz
+	}
+
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0].text, "@") {
+		if !p.descriptor {
+			return nil, newParseError(spec, tokens[0], -1, "descriptors are not enabled")
+		}
+		s, err := p.parseDescriptor(spec, tokens, loc)
+		if ts, ok := s.(*TimeSchedule); ok {
+			ts.dst = dst
+		}
+		return s, err
+	}
+
+	// 1.按空格分割字符串获取时间参数
+	params, err := p.expand(spec, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := p.parseFields(spec, params, weekDays)
+	if err != nil {
+		return nil, err
+	}
+	ts.dayOr = dayOr && !isStar(params[3].text) && !isStar(params[5].text)
+	ts.dst = dst
+	ts.loc = loc
+	return ts, nil
+}
+
+// parseFields 解析 秒 分 时 日 月 星期 年 共 7 个字段，week 为星期字段的取值范围
+func (p *Parser) parseFields(spec string, params []field, week bounds) (*TimeSchedule, error) {
+	var err error
+	ts := new(TimeSchedule)
+
+	// special: 解析字段特有的特殊符号，ok 为 true 表示已处理
+	f := func(i int, b bounds, special func(string) (uint64, bool, error)) (_time uint64, err error) {
+		b.lenient = p.lenient
+		if p.hashed {
+			b.hash, b.hashed = hashKey(p.key, fieldNames[i]), true
+		}
+		offset := params[i].offset
+		for _, comma := range strings.Split(params[i].text, ",") {
+			var (
+				_t uint64
+				ok bool
+			)
+			if special != nil {
+				_t, ok, err = special(comma)
+			}
+			if err == nil && !ok {
+				_t, err = parse(comma, b)
+			}
+			if err != nil {
+				tok := field{text: comma, offset: offset, index: params[i].index}
+				return 0, newParseError(spec, tok, i, err.Error())
+			}
+			_time |= _t
+			offset += len(comma) + 1
+		}
+
+		return
+	}
+
+	// 2.解析参数
+	if ts.second, err = f(0, seconds, nil); err != nil {
+		return nil, err
+	}
+	if ts.min, err = f(1, minutes, nil); err != nil {
+		return nil, err
+	}
+	if ts.hour, err = f(2, hours, nil); err != nil {
+		return nil, err
+	}
+	if ts.day, err = f(3, days, ts.parseDaySpecial); err != nil {
+		return nil, err
+	}
+	if ts.month, err = f(4, months, nil); err != nil {
+		return nil, err
+	}
+	weekDaySpecial := func(expr string) (uint64, bool, error) {
+		return ts.parseWeekDaySpecial(expr, week)
+	}
+	if ts.weekDay, err = f(5, week, weekDaySpecial); err != nil {
+		return nil, err
+	}
+	if ts.year, err = f(6, years, nil); err != nil {
+		return nil, err
+	}
+
+	// 3.修正不合法数据
+	ts.amend()
+
+	return ts, nil
+}
+
+// parsePrefixes 解析表达式开头的前缀，前缀的顺序不限:
+// CRON_TZ= 或 TZ= 指定时区
+// CRON_DAY_OR=1 或 CRON_DAY_OR=0 指定日期和星期是否满足其一即可，覆盖 WithDayOr 的设置
+// CRON_DST= 指定夏令时切换的执行策略(shift、skip、once、twice，多个用 ',' 分割)，覆盖 WithDST 的设置
+// 返回去掉前缀的字段、时区(没有指定时为 nil)、日期和星期的组合方式及夏令时切换的执行策略
+func (p *Parser) parsePrefixes(spec string, tokens []field) ([]field, *time.Location, bool, DSTPolicy, error) {
+	var loc *time.Location
+	dayOr, dst := p.dayOr, p.dst
+	n := 0
+prefixes:
+	for ; n < len(tokens); n++ {
+		var name string
+		switch text := tokens[n].text; {
+		case strings.HasPrefix(text, "CRON_TZ="):
+			name = strings.TrimPrefix(text, "CRON_TZ=")
+		case strings.HasPrefix(text, "TZ="):
+			name = strings.TrimPrefix(text, "TZ=")
+		case text == "CRON_DAY_OR=1", text == "CRON_DAY_OR=0":
+			dayOr = text == "CRON_DAY_OR=1"
+			continue
+		case strings.HasPrefix(text, "CRON_DAY_OR="):
+			return nil, nil, false, dst, newParseError(spec, tokens[n], -1, "CRON_DAY_OR expects 0 or 1")
+		case strings.HasPrefix(text, "CRON_DST="):
+			if !parseDST(strings.TrimPrefix(text, "CRON_DST="), &dst) {
+				return nil, nil, false, dst, newParseError(spec, tokens[n], -1, "CRON_DST expects shift, skip, once or twice")
+			}
+			continue
+		default:
+			break prefixes
+		}
+
+		l, err := time.LoadLocation(name)
+		if err != nil || name == "" {
+			return nil, nil, false, dst, newParseError(spec, tokens[n], -1, "unknown time zone")
+		}
+		loc = l
+	}
+
+	if n == 0 {
+		return tokens, loc, dayOr, dst, nil
+	}
+
+	// 前缀不计入字段序号
+	tokens = append([]field(nil), tokens[n:]...)
+	for i := range tokens {
+		tokens[i].index = i
+	}
+	return tokens, loc, dayOr, dst, nil
+}
+
+// hashKey 计算 key 在字段 name 上的散列值(FNV-1a)，不同字段的散列值相互独立
+func hashKey(key, name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+// isStar 字段是否没有限制
+func isStar(text string) bool {
+	return text == "*" || text == "?"
+}
+
+// field 表达式中以空白分割的字段
+type field struct {
+	text string
+
+	// 在表达式中的字节偏移，省略的字段为 -1
+	offset int
+
+	// 在表达式中的序号，省略的字段为 -1
+	index int
+}
+
+// splitFields 按空白分割表达式，并记录各字段的位置
+func splitFields(spec string) []field {
+	var fields []field
+	start := -1
+	for i, r := range spec + " " {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, field{text: spec[start:i], offset: start, index: len(fields)})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	return fields
+}
+
+// expand 按解析器配置补全省略的字段，返回 秒 分 时 日 月 星期 年 共 7 个字段
+func (p *Parser) expand(spec string, params []field) ([]field, error) {
+	min, max := 5, 5
+	for _, mode := range []fieldMode{p.second, p.year} {
+		switch mode {
+		case fieldRequired:
+			min++
+			max++
+		case fieldOptional:
+			max++
+		}
+	}
+
+	l := len(params)
+	if l < min || l > max {
+		e := &ParseError{Expr: spec, Field: -1, Offset: -1}
+		if min == max {
+			e.Reason = fmt.Sprintf("expected %d fields, got %d", min, l)
+		} else {
+			e.Reason = fmt.Sprintf("expected %d to %d fields, got %d", min, max, l)
+		}
+		return nil, e
+	}
+
+	// 可省略字段的数量，优先分配给秒字段
+	extra := l - min
+	hasSecond := p.second == fieldRequired
+	if p.second == fieldOptional && extra > 0 {
+		hasSecond = true
+		extra--
+	}
+	hasYear := p.year == fieldRequired || (p.year == fieldOptional && extra > 0)
+
+	fields := make([]field, 0, 7)
+	if !hasSecond {
+		fields = append(fields, field{text: "0", offset: -1, index: -1})
+	}
+	fields = append(fields, params...)
+	if !hasYear {
+		fields = append(fields, field{text: "*", offset: -1, index: -1})
+	}
+	return fields, nil
+}
+
+// fieldNames 展开后各字段的名称
+var fieldNames = [...]string{"second", "minute", "hour", "day", "month", "weekday", "year"}
+
+// ParseError 表达式解析错误，可通过 errors.Is(err, ErrInvialParam) 判断
+type ParseError struct {
+	// Expr 原始表达式
+	Expr string
+
+	// Field 出错字段在表达式中的序号(从 0 开始)，与具体字段无关时为 -1
+	Field int
+
+	// Name 出错字段的名称: second、minute、hour、day、month、weekday、year，与具体字段无关时为空
+	Name string
+
+	// Token 出错的内容
+	Token string
+
+	// Offset Token 在 Expr 中的字节偏移，与具体内容无关时为 -1
+	Offset int
+
+	// Reason 出错原因
+	Reason string
+}
+
+// newParseError 生成 tok 处的解析错误，i 为展开后的字段序号，-1 表示与具体字段无关
+func newParseError(spec string, tok field, i int, reason string) *ParseError {
+	e := &ParseError{
+		Expr:   spec,
+		Field:  -1,
+		Token:  tok.text,
+		Offset: tok.offset,
+		Reason: reason,
+	}
+	if i >= 0 {
+		e.Field = tok.index
+		e.Name = fieldNames[i]
+	}
+	return e
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Name != "":
+		return fmt.Sprintf("cron: invalid %s field %q at offset %d in %q: %s", e.Name, e.Token, e.Offset, e.Expr, e.Reason)
+	case e.Token != "":
+		return fmt.Sprintf("cron: invalid token %q at offset %d in %q: %s", e.Token, e.Offset, e.Expr, e.Reason)
+	}
+	return fmt.Sprintf("cron: invalid expression %q: %s", e.Expr, e.Reason)
+}
+
+// Unwrap 使 errors.Is(err, ErrInvialParam) 成立
+func (e *ParseError) Unwrap() error {
+	return ErrInvialParam
+}
@@ -0,0 +1,265 @@
+package corn
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_ParserParse(t *testing.T) {
+	data := []struct {
+		name   string
+		parser *Parser
+		expr   string
+		now    time.Time
+		next   time.Time
+	}{
+		{"5 个字段", NewParser(), "30 9 * * MON-FRI", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 20, 9, 30, 0, 0, time.Local)},
+		{"秒可选-省略", NewParser(WithSecondsOptional()), "30 9 * * *", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 18, 9, 30, 0, 0, time.Local)},
+		{"秒可选-包含", NewParser(WithSecondsOptional()), "15 30 9 * * *", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 18, 9, 30, 15, 0, time.Local)},
+		{"年可选-省略", NewParser(WithYearOptional()), "30 9 1 1 *", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2020, 1, 1, 9, 30, 0, 0, time.Local)},
+		{"年可选-包含", NewParser(WithYearOptional()), "30 9 1 1 * 2027", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2027, 1, 1, 9, 30, 0, 0, time.Local)},
+		{"秒、年可选-6 个字段", NewParser(WithSecondsOptional(), WithYearOptional()), "15 30 9 1 1 *", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2020, 1, 1, 9, 30, 15, 0, time.Local)},
+		{"秒、年可选-7 个字段", NewParser(WithSecondsOptional(), WithYearOptional()), "15 30 9 1 1 * 2027", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2027, 1, 1, 9, 30, 15, 0, time.Local)},
+		{"年必填", NewParser(WithYear()), "30 9 1 1 * 2027", time.Date(2019, 5, 18, 0, 0, 0, 0, time.Local), time.Date(2027, 1, 1, 9, 30, 0, 0, time.Local)},
+		{"描述符", NewParser(WithDescriptors()), "@daily", time.Date(2019, 5, 18, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 19, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := p.parser.Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := s.Next(p.now); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+}
+
+func Test_ParserParseError(t *testing.T) {
+	data := []struct {
+		name   string
+		parser *Parser
+		expr   string
+	}{
+		{"5 个字段-过少", NewParser(), "30 9 * *"},
+		{"5 个字段-过多", NewParser(), "0 30 9 * * *"},
+		{"秒必填", NewParser(WithSeconds()), "30 9 * * *"},
+		{"秒可选-过多", NewParser(WithSecondsOptional()), "0 30 9 * * * *"},
+		{"年必填", NewParser(WithYear()), "30 9 * * *"},
+		{"不支持描述符", NewParser(), "@daily"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			if _, err := p.parser.Parse(p.expr); err == nil {
+				t.Errorf("expr: %s, 期望返回错误", p.expr)
+			}
+		})
+	}
+}
+
+func Test_ParseError(t *testing.T) {
+	data := []struct {
+		name   string
+		parser *Parser
+		expr   string
+		want   ParseError
+	}{
+		{"取值错误", defaultParser, "0 x * * * *", ParseError{Field: 1, Name: "minute", Token: "x", Offset: 2, Reason: "invalid value"}},
+		{"列表中的取值错误", defaultParser, "0 0 1,2,FOO * * *", ParseError{Field: 2, Name: "hour", Token: "FOO", Offset: 8, Reason: "invalid value"}},
+		{"间隔错误", defaultParser, "0  */0 * * * *", ParseError{Field: 1, Name: "minute", Token: "*/0", Offset: 3, Reason: "invalid step"}},
+		{"范围错误", defaultParser, "0 0 0 1-2-3 * *", ParseError{Field: 3, Name: "day", Token: "1-2-3", Offset: 6, Reason: "invalid range"}},
+		{"星期错误", defaultParser, "0 0 0 ? * MON#9", ParseError{Field: 5, Name: "weekday", Token: "MON#9", Offset: 10, Reason: "invalid value"}},
+		{"年错误", defaultParser, "0 0 0 * * * 1999", ParseError{Field: 6, Name: "year", Token: "1999", Offset: 12, Reason: "invalid value"}},
+		{"省略秒字段", NewParser(), "0 0 x * *", ParseError{Field: 2, Name: "day", Token: "x", Offset: 4, Reason: "invalid value"}},
+		{"字段数量错误", defaultParser, "0 0 0 *", ParseError{Field: -1, Offset: -1, Reason: "expected 6 to 7 fields, got 4"}},
+		{"未知描述符", defaultParser, " @never", ParseError{Field: -1, Token: "@never", Offset: 1, Reason: "unknown descriptor"}},
+		{"描述符参数错误", defaultParser, "@every 1x", ParseError{Field: -1, Token: "1x", Offset: 7, Reason: "invalid duration"}},
+		{"不支持描述符", NewParser(), "@daily", ParseError{Field: -1, Token: "@daily", Offset: 0, Reason: "descriptors are not enabled"}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := p.parser.Parse(p.expr)
+			if !errors.Is(err, ErrInvialParam) {
+				t.Fatalf("err: %v, 期望为 ErrInvialParam", err)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("err: %v, 期望为 *ParseError", err)
+			}
+			p.want.Expr = p.expr
+			if *pe != p.want {
+				t.Errorf("want: %+v, get: %+v", p.want, *pe)
+			}
+			if pe.Offset >= 0 && p.expr[pe.Offset:pe.Offset+len(pe.Token)] != pe.Token {
+				t.Errorf("offset: %d 与 token: %s 不一致", pe.Offset, pe.Token)
+			}
+		})
+	}
+}
+
+func Test_ParserDayOr(t *testing.T) {
+	or := NewParser(WithSeconds(), WithDayOr())
+	data := []struct {
+		name   string
+		parser *Parser
+		expr   string
+		now    time.Time
+		next   time.Time
+	}{
+		{"且-日期和星期", defaultParser, "0 0 0 1,15 * 1", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 7, 1, 0, 0, 0, 0, time.Local)},
+		{"或-日期", or, "0 0 0 1,15 * 1", time.Date(2019, 5, 14, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 15, 0, 0, 0, 0, time.Local)},
+		{"或-星期", or, "0 0 0 1,15 * 1", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 6, 0, 0, 0, 0, time.Local)},
+		{"或-日期为 *", or, "0 0 0 * * 1", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 6, 0, 0, 0, 0, time.Local)},
+		{"或-星期为 ?", or, "0 0 0 15 * ?", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 15, 0, 0, 0, 0, time.Local)},
+		{"或-星期为 *", or, "0 0 0 15 * *", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 15, 0, 0, 0, 0, time.Local)},
+		{"或-特殊符号", or, "0 0 0 L * 5#1", time.Date(2019, 5, 1, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 3, 0, 0, 0, 0, time.Local)},
+		{"或-特殊符号月末", or, "0 0 0 L * 5#1", time.Date(2019, 5, 4, 1, 0, 0, 0, time.Local), time.Date(2019, 5, 31, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := p.parser.Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := s.Next(p.now); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+}
+
+func Test_ParserAWS(t *testing.T) {
+	aws := NewParser(WithAWS())
+	now := time.Date(2019, 5, 18, 10, 0, 0, 0, time.UTC) // 周六
+	data := []struct {
+		name string
+		expr string
+		next time.Time
+	}{
+		{"每天", "cron(0 12 * * ? *)", time.Date(2019, 5, 18, 12, 0, 0, 0, time.UTC)},
+		{"工作日", "cron(0 18 ? * MON-FRI *)", time.Date(2019, 5, 20, 18, 0, 0, 0, time.UTC)},
+		{"周日为 1", "cron(0 9 ? * 1 *)", time.Date(2019, 5, 19, 9, 0, 0, 0, time.UTC)},
+		{"周六为 7", "cron(0 9 ? * 7 *)", time.Date(2019, 5, 25, 9, 0, 0, 0, time.UTC)},
+		{"跨越周期", "cron(0 9 ? * 6-2 *)", time.Date(2019, 5, 19, 9, 0, 0, 0, time.UTC)},
+		{"每 15 分钟", "cron(0/15 * * * ? *)", time.Date(2019, 5, 18, 10, 15, 0, 0, time.UTC)},
+		{"每月 1 日", "cron(0 8 1 * ? *)", time.Date(2019, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{"最后一个周五", "cron(15 10 ? * 6L 2019-2022)", time.Date(2019, 5, 31, 10, 15, 0, 0, time.UTC)},
+		{"第一个周一", "cron(0 9 ? * 2#1 *)", time.Date(2019, 6, 3, 9, 0, 0, 0, time.UTC)},
+		{"最后一个工作日", "cron(0 9 LW * ? *)", time.Date(2019, 5, 31, 9, 0, 0, 0, time.UTC)},
+		{"时区", "CRON_TZ=Asia/Shanghai cron(0 9 * * ? *)", time.Date(2019, 5, 19, 1, 0, 0, 0, time.UTC)},
+		{"其它表达式", "0 11 * * *", time.Date(2019, 5, 18, 11, 0, 0, 0, time.Local)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := aws.Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := now
+			if p.next.Location() == time.Local {
+				now = time.Date(2019, 5, 18, 10, 0, 0, 0, time.Local)
+			}
+			if get := s.Next(now); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+
+	s, err := aws.Parse("rate(5 minutes)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := s.(*DurationSchedule); !ok || d.frequency != 5*time.Minute {
+		t.Errorf("get: %+v", s)
+	}
+	s, err = aws.Parse("rate(1 day)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := s.(*DurationSchedule); !ok || d.frequency != 24*time.Hour {
+		t.Errorf("get: %+v", s)
+	}
+}
+
+// 前缀和解析器选项对 cron() 同样有效
+func Test_ParserAWSPrefix(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 纽约 2019-03-10 02:00 拨快到 03:00
+	from := time.Date(2019, 3, 9, 12, 0, 0, 0, ny)
+	data := []struct {
+		name   string
+		parser *Parser
+		expr   string
+		next   time.Time
+	}{
+		{"默认顺延", NewParser(WithAWS()), "CRON_TZ=America/New_York cron(30 2 * * ? *)", time.Date(2019, 3, 10, 3, 30, 0, 0, ny)},
+		{"前缀跳过", NewParser(WithAWS()), "CRON_TZ=America/New_York CRON_DST=skip cron(30 2 * * ? *)", time.Date(2019, 3, 11, 2, 30, 0, 0, ny)},
+		{"选项跳过", NewParser(WithAWS(), WithDST(DSTPolicy{Gap: DSTSkip})), "CRON_TZ=America/New_York cron(30 2 * * ? *)", time.Date(2019, 3, 11, 2, 30, 0, 0, ny)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := p.parser.Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := s.Next(from); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+
+	s, err := NewParser(WithAWS()).Parse("CRON_DST=skip,twice cron(0 12 * * ? *)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get := s.(*TimeSchedule).String(); get != "CRON_TZ=UTC CRON_DST=skip,twice 0 0 12 * * *" {
+		t.Errorf("get: %s", get)
+	}
+}
+
+func Test_ParserAWSError(t *testing.T) {
+	aws := NewParser(WithAWS())
+	data := []struct {
+		name string
+		expr string
+		want ParseError
+	}{
+		{"日期和星期", "cron(0 12 * * * *)", ParseError{Field: 2, Name: "day", Token: "*", Offset: 10, Reason: "one of day-of-month and day-of-week must be ?"}},
+		{"字段数量", "cron(0 12 * * ?)", ParseError{Field: -1, Offset: -1, Reason: "cron() expects 6 fields, got 5"}},
+		{"缺少括号", "cron(0 12 * * ? *", ParseError{Field: -1, Token: "*", Offset: 16, Reason: "missing )"}},
+		{"星期超出范围", "cron(0 12 ? * 0 *)", ParseError{Field: 4, Name: "weekday", Token: "0", Offset: 14, Reason: "invalid value"}},
+		{"分钟", "cron(x 12 * * ? *)", ParseError{Field: 0, Name: "minute", Token: "x", Offset: 5, Reason: "invalid value"}},
+		{"间隔", "rate(0 minutes)", ParseError{Field: -1, Token: "0", Offset: 5, Reason: "invalid rate value"}},
+		{"单位", "rate(5 weeks)", ParseError{Field: -1, Token: "weeks", Offset: 7, Reason: "invalid rate unit"}},
+		{"rate 参数", "rate(5)", ParseError{Field: -1, Offset: -1, Reason: "rate() expects 2 fields, got 1"}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := aws.Parse(p.expr)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("err: %v", err)
+			}
+			p.want.Expr = p.expr
+			if *pe != p.want {
+				t.Errorf("want: %+v, get: %+v", p.want, *pe)
+			}
+		})
+	}
+
+	if _, err := Parse("cron(0 12 * * ? *)"); err == nil {
+		t.Error("未启用 WithAWS 时期望返回错误")
+	}
+}
@@ -0,0 +1,901 @@
+package corn
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rruleFreq 重复规则的频率，从细到粗排列
+type rruleFreq int
+
+const (
+	freqSecondly rruleFreq = iota
+	freqMinutely
+	freqHourly
+	freqDaily
+	freqWeekly
+	freqMonthly
+	freqYearly
+)
+
+var rruleFreqNames = [...]string{"SECONDLY", "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+
+var rruleWeekNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// rruleWeekDay BYDAY 的取值，n 为 0 时表示每个星期 weekday，否则表示第 n 个(负数表示倒数)
+type rruleWeekDay struct {
+	n       int
+	weekday time.Weekday
+}
+
+// RRuleSchedule RFC 5545 重复规则(RRULE)调度器，支持 DTSTART、EXDATE、RDATE
+// 执行时间按 DTSTART 所在时区的本地时间计算，DTSTART 总是第一次执行时间并计入 COUNT
+type RRuleSchedule struct {
+	dtstart  time.Time
+	freq     rruleFreq
+	interval int
+	count    int
+	until    time.Time
+
+	bySecond, byMinute, byHour, byMonth       []int
+	byMonthDay, byYearDay, byWeekNo, bySetPos []int
+	byDay                                     []rruleWeekDay
+	wkst                                      time.Weekday
+
+	// 额外的执行时间及排除的执行时间，按时间排序
+	rdates, exdates []time.Time
+
+	// 有 COUNT 时展开的规则执行时间
+	once    sync.Once
+	counted []time.Time
+}
+
+// ParseRRule 解析 RFC 5545 重复规则，可以是单独的规则:
+//  FREQ=MONTHLY;BYDAY=2TU;COUNT=10
+// 也可以是包含 DTSTART、RRULE、EXDATE、RDATE 的多行内容:
+//  DTSTART;TZID=Asia/Shanghai:20190520T090000
+//  RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20191231T160000Z
+//  EXDATE;TZID=Asia/Shanghai:20190524T090000
+//  RDATE;TZID=Asia/Shanghai:20190601T090000
+//
+// 支持 FREQ、INTERVAL、COUNT、UNTIL、BYSECOND、BYMINUTE、BYHOUR、BYDAY、BYMONTHDAY、
+// BYYEARDAY、BYWEEKNO、BYMONTH、BYSETPOS、WKST
+// 时间可以是 DATE(19970902)、本地时间(19970902T090000)、UTC 时间(19970902T090000Z)，
+// 本地时间使用 TZID 指定的时区，没有指定时使用 DTSTART 的时区(DTSTART 默认为 time.Local)
+// 省略 DTSTART 时使用解析时刻(精确到秒)
+// 解析失败时返回 *ParseError
+func ParseRRule(spec string) (*RRuleSchedule, error) {
+	props, err := splitRRuleLines(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RRuleSchedule{interval: 1, wkst: time.Monday}
+	var rule *rruleProp
+
+	// 先解析 DTSTART，其它时间默认使用 DTSTART 的时区
+	r.dtstart = time.Now().Truncate(time.Second)
+	for i := range props {
+		p := &props[i]
+		switch p.name {
+		case "DTSTART":
+			if r.dtstart, err = p.time(p.value, time.Local); err != nil {
+				return nil, p.errorf(spec, p.value, "invalid DTSTART")
+			}
+		case "RRULE":
+			if rule != nil {
+				return nil, p.errorf(spec, p.name, "multiple RRULE")
+			}
+			rule = p
+		case "EXDATE", "RDATE":
+		default:
+			return nil, p.errorf(spec, p.name, "unknown property")
+		}
+	}
+	if rule == nil {
+		return nil, &ParseError{Expr: spec, Field: -1, Offset: -1, Reason: "missing RRULE"}
+	}
+
+	for i := range props {
+		p := &props[i]
+		if p.name != "EXDATE" && p.name != "RDATE" {
+			continue
+		}
+		for _, v := range strings.Split(p.value, ",") {
+			t, err := p.time(v, r.dtstart.Location())
+			if err != nil {
+				return nil, p.errorf(spec, v, "invalid "+p.name)
+			}
+			if p.name == "EXDATE" {
+				r.exdates = append(r.exdates, t)
+			} else {
+				r.rdates = append(r.rdates, t)
+			}
+		}
+	}
+	sortTimes(r.exdates)
+	sortTimes(r.rdates)
+
+	if err := r.parseRule(spec, rule); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// rruleProp iCalendar 内容行，如 DTSTART;TZID=Asia/Shanghai:20190520T090000
+type rruleProp struct {
+	name   string
+	params map[string]string
+	value  string
+
+	// 内容行在表达式中的字节偏移
+	offset int
+	line   string
+}
+
+// splitRRuleLines 按行拆分表达式，支持以空白开头的折叠行，单独的规则视为 RRULE
+func splitRRuleLines(spec string) ([]rruleProp, error) {
+	var props []rruleProp
+	offset := 0
+	for _, line := range strings.SplitAfter(spec, "\n") {
+		start := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\r\n")
+
+		// 折叠行
+		if len(props) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			p := &props[len(props)-1]
+			p.line += line[1:]
+			p.value += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		p := rruleProp{params: map[string]string{}, offset: start, line: line}
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "FREQ=") {
+			p.name, p.value = "RRULE", strings.TrimSpace(line)
+			p.offset += strings.Index(line, p.value)
+			props = append(props, p)
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			return nil, &ParseError{Expr: spec, Field: -1, Token: line, Offset: start, Reason: "invalid content line"}
+		}
+		params := strings.Split(line[:colon], ";")
+		p.name, p.value = strings.ToUpper(strings.TrimSpace(params[0])), line[colon+1:]
+		for _, param := range params[1:] {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) != 2 {
+				return nil, &ParseError{Expr: spec, Field: -1, Token: param, Offset: start + strings.Index(line, param), Reason: "invalid parameter"}
+			}
+			p.params[strings.ToUpper(kv[0])] = kv[1]
+		}
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+// errorf 内容行中 token 处的解析错误
+func (p *rruleProp) errorf(spec, token, reason string) *ParseError {
+	offset := p.offset
+	if i := strings.Index(p.line, token); i >= 0 {
+		offset += i
+	}
+	return &ParseError{Expr: spec, Field: -1, Token: token, Offset: offset, Reason: reason}
+}
+
+// time 解析内容行中的时间，TZID 参数优先于 loc
+func (p *rruleProp) time(v string, loc *time.Location) (time.Time, error) {
+	if tzid, ok := p.params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+	return parseICalTime(v, loc)
+}
+
+// parseICalTime 解析 iCalendar 的 DATE 或 DATE-TIME，如 19970902、19970902T090000、19970902T090000Z
+func parseICalTime(v string, loc *time.Location) (time.Time, error) {
+	switch {
+	case len(v) == 8:
+		return time.ParseInLocation("20060102", v, loc)
+	case strings.HasSuffix(v, "Z"):
+		return time.Parse("20060102T150405Z", v)
+	}
+	return time.ParseInLocation("20060102T150405", v, loc)
+}
+
+// parseRule 解析 RRULE 的值，如 FREQ=MONTHLY;BYDAY=2TU;COUNT=10
+func (r *RRuleSchedule) parseRule(spec string, p *rruleProp) error {
+	hasFreq := false
+	for _, part := range strings.Split(p.value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return p.errorf(spec, part, "invalid rule part")
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			err = errValue
+			for f, name := range rruleFreqNames {
+				if value == name {
+					r.freq, hasFreq, err = rruleFreq(f), true, nil
+				}
+			}
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(value); err == nil && r.interval < 1 {
+				err = errValue
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(value); err == nil && r.count < 1 {
+				err = errValue
+			}
+		case "UNTIL":
+			r.until, err = parseICalTime(value, r.dtstart.Location())
+		case "BYSECOND":
+			r.bySecond, err = parseRRuleInts(value, 0, 59, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseRRuleInts(value, 0, 59, false)
+		case "BYHOUR":
+			r.byHour, err = parseRRuleInts(value, 0, 23, false)
+		case "BYMONTH":
+			r.byMonth, err = parseRRuleInts(value, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseRRuleInts(value, 1, 31, true)
+		case "BYYEARDAY":
+			r.byYearDay, err = parseRRuleInts(value, 1, 366, true)
+		case "BYWEEKNO":
+			r.byWeekNo, err = parseRRuleInts(value, 1, 53, true)
+		case "BYSETPOS":
+			r.bySetPos, err = parseRRuleInts(value, 1, 366, true)
+		case "BYDAY":
+			r.byDay, err = parseRRuleWeekDays(value)
+		case "WKST":
+			var w rruleWeekDay
+			if w, err = parseRRuleWeekDay(value); err == nil && w.n != 0 {
+				err = errValue
+			}
+			r.wkst = w.weekday
+		default:
+			return p.errorf(spec, part, "unknown rule part")
+		}
+		if err != nil {
+			return p.errorf(spec, part, "invalid "+key)
+		}
+	}
+
+	if !hasFreq {
+		return p.errorf(spec, p.value, "missing FREQ")
+	}
+	if reason := r.validate(); reason != "" {
+		return p.errorf(spec, p.value, reason)
+	}
+	return nil
+}
+
+// validate 检查规则各部分的组合是否符合 RFC 5545，返回不符合的原因
+func (r *RRuleSchedule) validate() string {
+	switch {
+	case r.count > 0 && !r.until.IsZero():
+		return "COUNT and UNTIL are mutually exclusive"
+	case len(r.byMonthDay) > 0 && r.freq == freqWeekly:
+		return "BYMONTHDAY is not allowed with FREQ=WEEKLY"
+	case len(r.byYearDay) > 0 && (r.freq == freqDaily || r.freq == freqWeekly || r.freq == freqMonthly):
+		return "BYYEARDAY is not allowed with FREQ=" + rruleFreqNames[r.freq]
+	case len(r.byWeekNo) > 0 && r.freq != freqYearly:
+		return "BYWEEKNO is only allowed with FREQ=YEARLY"
+	case len(r.bySetPos) > 0 && len(r.bySecond)+len(r.byMinute)+len(r.byHour)+len(r.byMonth)+len(r.byMonthDay)+
+		len(r.byYearDay)+len(r.byWeekNo)+len(r.byDay) == 0:
+		return "BYSETPOS requires another BYxxx rule part"
+	}
+	for _, d := range r.byDay {
+		if d.n != 0 && (r.freq != freqMonthly && r.freq != freqYearly || r.freq == freqYearly && len(r.byWeekNo) > 0) {
+			return "BYDAY with ordinal is only allowed with FREQ=MONTHLY or FREQ=YEARLY"
+		}
+	}
+	return ""
+}
+
+// parseRRuleInts 解析整数列表，signed 为 true 时可以为负数(表示倒数)
+func parseRRuleInts(value string, min, max int, signed bool) ([]int, error) {
+	var res []int
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		abs := v
+		if signed && v < 0 {
+			abs = -v
+		}
+		if abs < min || abs > max {
+			return nil, errValue
+		}
+		res = append(res, v)
+	}
+	sort.Ints(res)
+	return res, nil
+}
+
+// parseRRuleWeekDays 解析 BYDAY 列表，如 MO,WE,FR 或 2TU,-1FR
+func parseRRuleWeekDays(value string) ([]rruleWeekDay, error) {
+	var res []rruleWeekDay
+	for _, item := range strings.Split(value, ",") {
+		w, err := parseRRuleWeekDay(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, nil
+}
+
+// parseRRuleWeekDay 解析 [+-]n 星期，如 MO、2TU、-1FR
+func parseRRuleWeekDay(item string) (rruleWeekDay, error) {
+	if len(item) < 2 {
+		return rruleWeekDay{}, errValue
+	}
+	name, num := item[len(item)-2:], item[:len(item)-2]
+	w := rruleWeekDay{weekday: -1}
+	for i, n := range rruleWeekNames {
+		if n == name {
+			w.weekday = time.Weekday(i)
+		}
+	}
+	if w.weekday < 0 {
+		return w, errValue
+	}
+	if num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return w, errValue
+		}
+		w.n = n
+	}
+	return w, nil
+}
+
+// sortTimes 按时间排序
+func sortTimes(ts []time.Time) {
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+}
+
+// Next 晚于 t 的下一次执行时间，没有时返回零时
+func (r *RRuleSchedule) Next(t time.Time) time.Time {
+	next := t
+	for {
+		if next = r.ruleNext(next); next.IsZero() || !r.excluded(next) {
+			break
+		}
+	}
+
+	i := sort.Search(len(r.rdates), func(i int) bool { return r.rdates[i].After(t) })
+	for ; i < len(r.rdates) && r.excluded(r.rdates[i]); i++ {
+	}
+	if i < len(r.rdates) && (next.IsZero() || r.rdates[i].Before(next)) {
+		next = r.rdates[i]
+	}
+
+	if next.IsZero() {
+		return next
+	}
+	return next.In(t.Location())
+}
+
+// Last 最后一次执行时间，规则没有 COUNT 和 UNTIL 时返回零时
+func (r *RRuleSchedule) Last() time.Time {
+	var last time.Time
+	switch {
+	case r.count > 0:
+		set := r.countedSet()
+		for i := len(set) - 1; i >= 0; i-- {
+			if !r.excluded(set[i]) {
+				last = set[i]
+				break
+			}
+		}
+	case !r.until.IsZero():
+		last = prev(r, r.until.Add(time.Nanosecond))
+	default:
+		return time.Time{}
+	}
+
+	for i := len(r.rdates) - 1; i >= 0; i-- {
+		if !r.excluded(r.rdates[i]) {
+			if r.rdates[i].After(last) {
+				last = r.rdates[i]
+			}
+			break
+		}
+	}
+	return last
+}
+
+// excluded t 是否被 EXDATE 排除
+func (r *RRuleSchedule) excluded(t time.Time) bool {
+	i := sort.Search(len(r.exdates), func(i int) bool { return !r.exdates[i].Before(t) })
+	return i < len(r.exdates) && r.exdates[i].Equal(t)
+}
+
+// ruleNext 规则(含 DTSTART，不含 RDATE、EXDATE)中晚于 t 的下一次执行时间
+func (r *RRuleSchedule) ruleNext(t time.Time) time.Time {
+	if t.Before(r.dtstart) {
+		return r.dtstart
+	}
+
+	if r.count > 0 {
+		set := r.countedSet()
+		i := sort.Search(len(set), func(i int) bool { return set[i].After(t) })
+		if i < len(set) {
+			return set[i]
+		}
+		return time.Time{}
+	}
+
+	var next time.Time
+	r.each(t, func(o time.Time) bool {
+		if o.After(t) {
+			next = o
+			return false
+		}
+		return true
+	})
+	return next
+}
+
+// countedSet 有 COUNT 时规则的全部执行时间
+func (r *RRuleSchedule) countedSet() []time.Time {
+	r.once.Do(func() {
+		r.counted = []time.Time{r.dtstart}
+		r.each(r.dtstart, func(o time.Time) bool {
+			if len(r.counted) >= r.count {
+				return false
+			}
+			r.counted = append(r.counted, o)
+			return true
+		})
+	})
+	return r.counted
+}
+
+// each 从 t 所在的周期开始按时间顺序遍历规则产生的晚于 DTSTART 的执行时间，fn 返回 false 时停止
+// 超过 UNTIL 或连续 searchYears 年没有执行时间时停止
+func (r *RRuleSchedule) each(t time.Time, fn func(time.Time) bool) {
+	nt := r.naive(t)
+	limit := nt.AddDate(searchYears, 0, 0)
+	var until time.Time
+	if !r.until.IsZero() {
+		until = r.naive(r.until)
+	}
+
+	k := floorDiv(r.units(nt), r.interval)
+	if k < 0 {
+		k = 0
+	}
+	for {
+		start := r.periodStart(k)
+		if start.After(limit) || !until.IsZero() && start.After(until) {
+			return
+		}
+
+		occ := r.expand(start)
+		for _, o := range occ {
+			ot := r.real(o)
+			if !ot.After(r.dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && ot.After(r.until) {
+				return
+			}
+			if !fn(ot) {
+				return
+			}
+			limit = o.AddDate(searchYears, 0, 0)
+		}
+		k = r.nextPeriod(k, start, len(occ) == 0)
+	}
+}
+
+// naive 将 t 转为 DTSTART 时区的本地时间，以 UTC 表示，规则按本地时间计算
+func (r *RRuleSchedule) naive(t time.Time) time.Time {
+	t = t.In(r.dtstart.Location())
+	y, m, d := t.Date()
+	h, mi, s := t.Clock()
+	return time.Date(y, m, d, h, mi, s, 0, time.UTC)
+}
+
+// real 将本地时间转为 DTSTART 时区的时间
+func (r *RRuleSchedule) real(n time.Time) time.Time {
+	y, m, d := n.Date()
+	h, mi, s := n.Clock()
+	return time.Date(y, m, d, h, mi, s, 0, r.dtstart.Location())
+}
+
+// periodStart 第 k 个周期的开始时间(本地时间)
+func (r *RRuleSchedule) periodStart(k int) time.Time {
+	n := k * r.interval
+	s := r.naive(r.dtstart)
+	y, m, d := s.Date()
+	h, mi, sec := s.Clock()
+	switch r.freq {
+	case freqYearly:
+		return time.Date(y+n, 1, 1, 0, 0, 0, 0, time.UTC)
+	case freqMonthly:
+		return time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	case freqWeekly:
+		return time.Date(y, m, d-r.weekOffset(s.Weekday())+7*n, 0, 0, 0, 0, time.UTC)
+	case freqDaily:
+		return time.Date(y, m, d+n, 0, 0, 0, 0, time.UTC)
+	case freqHourly:
+		return time.Date(y, m, d, h+n, 0, 0, 0, time.UTC)
+	case freqMinutely:
+		return time.Date(y, m, d, h, mi+n, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, h, mi, sec+n, 0, time.UTC)
+}
+
+// units 本地时间 n 所在周期与 DTSTART 所在周期相差的频率单位数
+func (r *RRuleSchedule) units(n time.Time) int {
+	s := r.naive(r.dtstart)
+	days := civilDay(n) - civilDay(s)
+	switch r.freq {
+	case freqYearly:
+		return n.Year() - s.Year()
+	case freqMonthly:
+		return (n.Year()-s.Year())*12 + int(n.Month()) - int(s.Month())
+	case freqWeekly:
+		return floorDiv(days+r.weekOffset(s.Weekday()), 7)
+	case freqDaily:
+		return days
+	}
+
+	hours := days*24 + n.Hour() - s.Hour()
+	switch r.freq {
+	case freqHourly:
+		return hours
+	case freqMinutely:
+		return hours*60 + n.Minute() - s.Minute()
+	}
+	return (hours*60+n.Minute()-s.Minute())*60 + n.Second() - s.Second()
+}
+
+// weekOffset 星期 w 距离一周开始(WKST)的天数
+func (r *RRuleSchedule) weekOffset(w time.Weekday) int {
+	return int(7+w-r.wkst) % 7
+}
+
+// nextPeriod 下一个需要展开的周期
+// 小于一天的频率在周期没有执行时间时，跳过日期、小时、分钟不符合要求的周期
+func (r *RRuleSchedule) nextPeriod(k int, start time.Time, empty bool) int {
+	if !empty || r.freq >= freqDaily {
+		return k + 1
+	}
+
+	y, m, d := start.Date()
+	h, mi, _ := start.Clock()
+	var next time.Time
+	switch {
+	case !r.matchDate(start):
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+	case r.freq < freqHourly && !matchInts(r.byHour, h):
+		next = time.Date(y, m, d, h+1, 0, 0, 0, time.UTC)
+	case r.freq < freqMinutely && !matchInts(r.byMinute, mi):
+		next = time.Date(y, m, d, h, mi+1, 0, 0, time.UTC)
+	default:
+		return k + 1
+	}
+	if n := -floorDiv(-r.units(next), r.interval); n > k {
+		return n
+	}
+	return k + 1
+}
+
+// expand 展开从 start 开始的周期内的执行时间(本地时间)，按时间排序并应用 BYSETPOS
+func (r *RRuleSchedule) expand(start time.Time) []time.Time {
+	var dates []time.Time
+	switch r.freq {
+	case freqYearly:
+		for d := start; d.Year() == start.Year(); d = d.AddDate(0, 0, 1) {
+			dates = append(dates, d)
+		}
+	case freqMonthly:
+		for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+			dates = append(dates, d)
+		}
+	case freqWeekly:
+		for i := 0; i < 7; i++ {
+			dates = append(dates, start.AddDate(0, 0, i))
+		}
+	default:
+		dates = append(dates, start)
+	}
+
+	s := r.naive(r.dtstart)
+	hours := r.clockValues(freqHourly, r.byHour, s.Hour(), start.Hour())
+	minutes := r.clockValues(freqMinutely, r.byMinute, s.Minute(), start.Minute())
+	seconds := r.clockValues(freqSecondly, r.bySecond, s.Second(), start.Second())
+
+	var res []time.Time
+	for _, d := range dates {
+		if !r.matchDate(d) {
+			continue
+		}
+		y, m, day := d.Date()
+		for _, h := range hours {
+			for _, mi := range minutes {
+				for _, sec := range seconds {
+					res = append(res, time.Date(y, m, day, h, mi, sec, 0, time.UTC))
+				}
+			}
+		}
+	}
+
+	if len(r.bySetPos) == 0 {
+		return res
+	}
+	var set []time.Time
+	for _, pos := range r.bySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(res) + pos
+		}
+		if i >= 0 && i < len(res) {
+			set = append(set, res[i])
+		}
+	}
+	sortTimes(set)
+	return set
+}
+
+// clockValues 周期内时、分、秒的取值
+// 频率比 unit 粗时取 by 的值(没有时取 DTSTART 的值)，否则为周期开始时间的值(需要符合 by)
+func (r *RRuleSchedule) clockValues(unit rruleFreq, by []int, dtstart, period int) []int {
+	if r.freq > unit {
+		if len(by) > 0 {
+			return by
+		}
+		return []int{dtstart}
+	}
+	if matchInts(by, period) {
+		return []int{period}
+	}
+	return nil
+}
+
+// matchInts by 为空或包含 v
+func matchInts(by []int, v int) bool {
+	if len(by) == 0 {
+		return true
+	}
+	for _, b := range by {
+		if b == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSigned by 为空或包含 v，负数表示倒数，n 为总数
+func matchSigned(by []int, v, n int) bool {
+	if len(by) == 0 {
+		return true
+	}
+	for _, b := range by {
+		if b == v || b < 0 && n+b+1 == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDate 日期是否符合 BYMONTH、BYWEEKNO、BYYEARDAY、BYMONTHDAY、BYDAY
+// 都没有指定时按 DTSTART 补全: 每年为 DTSTART 的月、日，每月为 DTSTART 的日，每周为 DTSTART 的星期
+func (r *RRuleSchedule) matchDate(d time.Time) bool {
+	y, m, day := d.Date()
+	s := r.naive(r.dtstart)
+	byMonth, byMonthDay, byDay := r.byMonth, r.byMonthDay, r.byDay
+	if len(r.byWeekNo)+len(r.byYearDay)+len(r.byMonthDay)+len(r.byDay) == 0 {
+		switch r.freq {
+		case freqYearly:
+			if len(byMonth) == 0 {
+				byMonth = []int{int(s.Month())}
+			}
+			byMonthDay = []int{s.Day()}
+		case freqMonthly:
+			byMonthDay = []int{s.Day()}
+		case freqWeekly:
+			byDay = []rruleWeekDay{{weekday: s.Weekday()}}
+		}
+	}
+
+	dim, diy := daysIn(y, m), time.Date(y, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	if !matchInts(byMonth, int(m)) ||
+		!matchSigned(byMonthDay, day, dim) ||
+		!matchSigned(r.byYearDay, d.YearDay(), diy) {
+		return false
+	}
+
+	if len(r.byWeekNo) > 0 {
+		start := weekOneStart(y, r.wkst)
+		weeks := civilDay(weekOneStart(y+1, r.wkst)) - civilDay(start)
+		n := floorDiv(civilDay(d)-civilDay(start), 7) + 1
+		if n < 1 || n > weeks/7 || !matchSigned(r.byWeekNo, n, weeks/7) {
+			return false
+		}
+	}
+
+	if len(byDay) == 0 {
+		return true
+	}
+	// 第 n 个星期在每月或每年(指定 BYMONTH 时为每月)中计算
+	inMonth := r.freq == freqMonthly || len(r.byMonth) > 0
+	for _, w := range byDay {
+		if w.weekday != d.Weekday() {
+			continue
+		}
+		switch {
+		case w.n == 0:
+			return true
+		case inMonth && w.n > 0 && (day-1)/7+1 == w.n,
+			inMonth && w.n < 0 && (dim-day)/7+1 == -w.n,
+			!inMonth && w.n > 0 && (d.YearDay()-1)/7+1 == w.n,
+			!inMonth && w.n < 0 && (diy-d.YearDay())/7+1 == -w.n:
+			return true
+		}
+	}
+	return false
+}
+
+// weekOneStart 第 year 年第 1 周的开始日期，第 1 周是第一个至少有 4 天在该年内的周
+func weekOneStart(year int, wkst time.Weekday) time.Time {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	offset := int(7+wkst-jan1.Weekday()) % 7
+	if offset >= 4 {
+		offset -= 7
+	}
+	return jan1.AddDate(0, 0, offset)
+}
+
+// civilDay 日期距离 1970-01-01 的天数
+func civilDay(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// floorDiv 向下取整的除法
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// String 规范的 iCalendar 内容，可以被 ParseRRule 解析，如:
+//  DTSTART;TZID=Asia/Shanghai:20190520T090000
+//  RRULE:FREQ=MONTHLY;COUNT=10;BYDAY=2TU
+func (r *RRuleSchedule) String() string {
+	lines := []string{icalProp("DTSTART", r.dtstart.Location(), r.dtstart), "RRULE:" + r.Rule()}
+	if len(r.exdates) > 0 {
+		lines = append(lines, icalProp("EXDATE", r.dtstart.Location(), r.exdates...))
+	}
+	if len(r.rdates) > 0 {
+		lines = append(lines, icalProp("RDATE", r.dtstart.Location(), r.rdates...))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Rule RRULE 的值，如 FREQ=MONTHLY;COUNT=10;BYDAY=2TU
+// DTSTART 为本地时间(time.Local)时 UNTIL 也为本地时间，否则为 UTC 时间
+func (r *RRuleSchedule) Rule() string {
+	return r.rule(r.dtstart.Location() != time.Local)
+}
+
+// rule RRULE 的值，utcUntil 为 false 时 UNTIL 为本地时间
+func (r *RRuleSchedule) rule(utcUntil bool) string {
+	parts := []string{"FREQ=" + rruleFreqNames[r.freq]}
+	if r.interval != 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.interval))
+	}
+	if r.count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.count))
+	}
+	if !r.until.IsZero() {
+		if !utcUntil {
+			parts = append(parts, "UNTIL="+r.until.In(time.Local).Format("20060102T150405"))
+		} else {
+			parts = append(parts, "UNTIL="+r.until.UTC().Format("20060102T150405Z"))
+		}
+	}
+
+	ints := func(name string, vs []int) {
+		if len(vs) == 0 {
+			return
+		}
+		items := make([]string, len(vs))
+		for i, v := range vs {
+			items[i] = strconv.Itoa(v)
+		}
+		parts = append(parts, name+"="+strings.Join(items, ","))
+	}
+	ints("BYMONTH", r.byMonth)
+	ints("BYWEEKNO", r.byWeekNo)
+	ints("BYYEARDAY", r.byYearDay)
+	ints("BYMONTHDAY", r.byMonthDay)
+	if len(r.byDay) > 0 {
+		items := make([]string, len(r.byDay))
+		for i, w := range r.byDay {
+			items[i] = rruleWeekNames[w.weekday]
+			if w.n != 0 {
+				items[i] = strconv.Itoa(w.n) + items[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(items, ","))
+	}
+	ints("BYHOUR", r.byHour)
+	ints("BYMINUTE", r.byMinute)
+	ints("BYSECOND", r.bySecond)
+	ints("BYSETPOS", r.bySetPos)
+	if r.wkst != time.Monday {
+		parts = append(parts, "WKST="+rruleWeekNames[r.wkst])
+	}
+	return strings.Join(parts, ";")
+}
+
+// icalProp 格式化包含时间的内容行
+// loc 为 UTC 时使用 UTC 时间，为 time.Local 时使用本地时间，否则使用 TZID 参数
+func icalProp(name string, loc *time.Location, ts ...time.Time) string {
+	items := make([]string, len(ts))
+	for i, t := range ts {
+		if loc == time.UTC {
+			items[i] = t.UTC().Format("20060102T150405Z")
+		} else {
+			items[i] = t.In(loc).Format("20060102T150405")
+		}
+	}
+	if loc != time.UTC && loc != time.Local {
+		name += ";TZID=" + loc.String()
+	}
+	return name + ":" + strings.Join(items, ",")
+}
+
+// MarshalText 实现 encoding.TextMarshaler，内容同 String
+func (r *RRuleSchedule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，使用 ParseRRule 解析
+func (r *RRuleSchedule) UnmarshalText(text []byte) error {
+	s, err := ParseRRule(string(text))
+	if err != nil {
+		return err
+	}
+	r.dtstart, r.freq, r.interval, r.count, r.until = s.dtstart, s.freq, s.interval, s.count, s.until
+	r.bySecond, r.byMinute, r.byHour, r.byMonth = s.bySecond, s.byMinute, s.byHour, s.byMonth
+	r.byMonthDay, r.byYearDay, r.byWeekNo, r.bySetPos = s.byMonthDay, s.byYearDay, s.byWeekNo, s.bySetPos
+	r.byDay, r.wkst, r.rdates, r.exdates = s.byDay, s.wkst, s.rdates, s.exdates
+	r.once, r.counted = sync.Once{}, nil
+	return nil
+}
+
+// Describe 用自然语言描述重复规则
+func (r *RRuleSchedule) Describe(lang Lang) string {
+	if lang == LangEn {
+		return fmt.Sprintf("Repeats %s (%s), starting at %s", strings.ToLower(rruleFreqNames[r.freq]), r.Rule(), r.dtstart.Format(describeLayout))
+	}
+	return fmt.Sprintf("从 %s 开始按规则 %s 重复", r.dtstart.Format(describeLayout), r.Rule())
+}
+
+var _ Scheduler = new(RRuleSchedule)
@@ -0,0 +1,254 @@
+package corn
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// RFC 5545 3.8.5.3 中的示例
+func Test_RRuleSchedule(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	d := func(y int, m time.Month, day, h, mi int) time.Time {
+		return time.Date(y, m, day, h, mi, 0, 0, ny)
+	}
+	days := func(y int, m time.Month, ds ...int) []time.Time {
+		var res []time.Time
+		for _, day := range ds {
+			res = append(res, d(y, m, day, 9, 0))
+		}
+		return res
+	}
+	join := func(ts ...[]time.Time) []time.Time {
+		var res []time.Time
+		for _, t := range ts {
+			res = append(res, t...)
+		}
+		return res
+	}
+
+	data := []struct {
+		name string
+		spec string
+		want []time.Time
+	}{
+		{"每天 10 次", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;COUNT=10",
+			days(1997, 9, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)},
+		{"隔天", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=2",
+			days(1997, 9, 2, 4, 6, 8, 10)},
+		{"每 10 天 5 次", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=10;COUNT=5",
+			join(days(1997, 9, 2, 12, 22), days(1997, 10, 2, 12))},
+		{"每周 10 次", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;COUNT=10",
+			join(days(1997, 9, 2, 9, 16, 23, 30), days(1997, 10, 7, 14, 21, 28), days(1997, 11, 4))},
+		{"隔周周一三五", "DTSTART;TZID=America/New_York:19970901T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;WKST=SU;BYDAY=MO,WE,FR",
+			join(days(1997, 9, 1, 3, 5, 15, 17, 19, 29), days(1997, 10, 1, 3, 13, 15, 17, 27, 29, 31),
+				days(1997, 11, 10, 12, 14, 24, 26, 28), days(1997, 12, 8, 10, 12, 22))},
+		{"每月第一个周五", "DTSTART;TZID=America/New_York:19970905T090000\nRRULE:FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			join(days(1997, 9, 5), days(1997, 10, 3), days(1997, 11, 7), days(1997, 12, 5), days(1998, 1, 2),
+				days(1998, 2, 6), days(1998, 3, 6), days(1998, 4, 3), days(1998, 5, 1), days(1998, 6, 5))},
+		{"每月倒数第二个周一", "DTSTART;TZID=America/New_York:19970922T090000\nRRULE:FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			join(days(1997, 9, 22), days(1997, 10, 20), days(1997, 11, 17), days(1997, 12, 22), days(1998, 1, 19), days(1998, 2, 16))},
+		{"每月倒数第三天", "DTSTART;TZID=America/New_York:19970928T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=-3",
+			join(days(1997, 9, 28), days(1997, 10, 29), days(1997, 11, 28), days(1997, 12, 29), days(1998, 1, 29), days(1998, 2, 26))},
+		{"每年 6、7 月", "DTSTART;TZID=America/New_York:19970610T090000\nRRULE:FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+			join(days(1997, 6, 10), days(1997, 7, 10), days(1998, 6, 10), days(1998, 7, 10), days(1999, 6, 10),
+				days(1999, 7, 10), days(2000, 6, 10), days(2000, 7, 10), days(2001, 6, 10), days(2001, 7, 10))},
+		{"每 3 年的第 1、100、200 天", "DTSTART;TZID=America/New_York:19970101T090000\nRRULE:FREQ=YEARLY;INTERVAL=3;COUNT=10;BYYEARDAY=1,100,200",
+			join(days(1997, 1, 1), days(1997, 4, 10), days(1997, 7, 19), days(2000, 1, 1), days(2000, 4, 9),
+				days(2000, 7, 18), days(2003, 1, 1), days(2003, 4, 10), days(2003, 7, 19), days(2006, 1, 1))},
+		{"第 20 周的周一", "DTSTART;TZID=America/New_York:19970512T090000\nRRULE:FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO",
+			join(days(1997, 5, 12), days(1998, 5, 11), days(1999, 5, 17))},
+		{"13 号星期五", "DTSTART;TZID=America/New_York:19970902T090000\nEXDATE;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			join(days(1998, 2, 13), days(1998, 3, 13), days(1998, 11, 13), days(1999, 8, 13), days(2000, 10, 13))},
+		{"美国大选日", "DTSTART;TZID=America/New_York:19961105T090000\nRRULE:FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8",
+			join(days(1996, 11, 5), days(2000, 11, 7), days(2004, 11, 2))},
+		{"第三个周二三四", "DTSTART;TZID=America/New_York:19970904T090000\nRRULE:FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+			join(days(1997, 9, 4), days(1997, 10, 7), days(1997, 11, 6))},
+		{"倒数第二个工作日", "DTSTART;TZID=America/New_York:19970929T090000\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2",
+			join(days(1997, 9, 29), days(1997, 10, 30), days(1997, 11, 27), days(1997, 12, 30), days(1998, 1, 29), days(1998, 2, 26))},
+		{"每 15 分钟 6 次", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MINUTELY;INTERVAL=15;COUNT=6",
+			[]time.Time{d(1997, 9, 2, 9, 0), d(1997, 9, 2, 9, 15), d(1997, 9, 2, 9, 30), d(1997, 9, 2, 9, 45), d(1997, 9, 2, 10, 0), d(1997, 9, 2, 10, 15)}},
+		{"WKST=MO", "DTSTART;TZID=America/New_York:19970805T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			days(1997, 8, 5, 10, 19, 24)},
+		{"WKST=SU", "DTSTART;TZID=America/New_York:19970805T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			days(1997, 8, 5, 17, 19, 31)},
+		{"忽略无效日期", "DTSTART;TZID=America/New_York:20070115T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5",
+			join(days(2007, 1, 15, 30), days(2007, 2, 15), days(2007, 3, 15, 30))},
+		{"RDATE", "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;COUNT=3\nRDATE;TZID=America/New_York:19970903T120000,19970910T090000",
+			[]time.Time{d(1997, 9, 2, 9, 0), d(1997, 9, 3, 9, 0), d(1997, 9, 3, 12, 0), d(1997, 9, 4, 9, 0), d(1997, 9, 10, 9, 0)}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := ParseRRule(p.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := NextN(s, d(1996, 1, 1, 0, 0), len(p.want)+1)
+			if len(get) < len(p.want) {
+				t.Fatalf("want: %d 次, get: %v", len(p.want), get)
+			}
+			for i, want := range p.want {
+				if !get[i].Equal(want) {
+					t.Fatalf("第 %d 次 want: %s, get: %s", i, want, get[i])
+				}
+			}
+			if s.count > 0 && len(get) != len(p.want) {
+				t.Errorf("COUNT 之后还有执行时间: %s", get[len(p.want)])
+			}
+		})
+	}
+}
+
+func Test_RRuleScheduleEquivalent(t *testing.T) {
+	// RFC 5545: 每天 9:00 到 16:40 每 20 分钟，两种写法结果相同
+	start := "DTSTART;TZID=America/New_York:19970902T090000\n"
+	a, err := ParseRRule(start + "RRULE:FREQ=DAILY;BYHOUR=9,10,11,12,13,14,15,16;BYMINUTE=0,20,40")
+	if err != nil {
+		t.Skip(err)
+	}
+	b, err := ParseRRule(start + "RRULE:FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10,11,12,13,14,15,16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(1997, 9, 1, 0, 0, 0, 0, time.UTC)
+	as, bs := NextN(a, from, 60), NextN(b, from, 60)
+	for i := range as {
+		if !as[i].Equal(bs[i]) {
+			t.Fatalf("第 %d 次: %s != %s", i, as[i], bs[i])
+		}
+	}
+	if last := as[len(as)-1].In(a.dtstart.Location()); last.Day() != 4 || last.Hour() != 12 || last.Minute() != 40 {
+		t.Errorf("第 60 次: %s", last)
+	}
+}
+
+func Test_RRuleScheduleNextFar(t *testing.T) {
+	s, err := ParseRRule("DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY;INTERVAL=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []struct {
+		now, next time.Time
+	}{
+		{time.Date(2019, 5, 20, 8, 0, 0, 0, time.UTC), time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC), time.Date(2019, 5, 22, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, p := range data {
+		if get := s.Next(p.now); !get.Equal(p.next) {
+			t.Errorf("want: %s, get: %s", p.next, get)
+		}
+	}
+
+	// 小于一天的频率跳过不符合要求的日期
+	s, err = ParseRRule("DTSTART:19970902T090000Z\nRRULE:FREQ=SECONDLY;INTERVAL=7;BYMONTH=1;BYHOUR=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := s.Next(time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC))
+	if get.Month() != time.January || get.Year() != 2020 || get.Hour() != 3 || get.Day() != 1 || get.Minute() != 0 {
+		t.Errorf("get: %s", get)
+	}
+	if get.Sub(time.Date(1997, 9, 2, 9, 0, 0, 0, time.UTC))%(7*time.Second) != 0 {
+		t.Errorf("get: %s 不是 7 秒的整数倍", get)
+	}
+}
+
+func Test_RRuleScheduleLast(t *testing.T) {
+	data := []struct {
+		name string
+		spec string
+		last time.Time
+	}{
+		{"COUNT", "DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY;COUNT=10", time.Date(1997, 9, 11, 9, 0, 0, 0, time.UTC)},
+		{"COUNT 排除最后一次", "DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY;COUNT=10\nEXDATE:19970911T090000Z", time.Date(1997, 9, 10, 9, 0, 0, 0, time.UTC)},
+		{"UNTIL", "DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY;UNTIL=19971224T000000Z", time.Date(1997, 12, 23, 9, 0, 0, 0, time.UTC)},
+		{"UNTIL 之后的 RDATE", "DTSTART:19970902T090000Z\nRRULE:FREQ=WEEKLY;UNTIL=19971224T000000Z\nRDATE:19980101T000000Z", time.Date(1998, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"没有限制", "DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY", time.Time{}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			s, err := ParseRRule(p.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := s.Last(); !get.Equal(p.last) {
+				t.Errorf("want: %s, get: %s", p.last, get)
+			}
+		})
+	}
+}
+
+func Test_ParseRRuleError(t *testing.T) {
+	data := []struct {
+		name   string
+		spec   string
+		token  string
+		reason string
+	}{
+		{"缺少 FREQ", "COUNT=10", "COUNT=10", "invalid content line"},
+		{"缺少 RRULE", "DTSTART:19970902T090000Z", "", "missing RRULE"},
+		{"无效 FREQ", "FREQ=FORTNIGHTLY", "FREQ=FORTNIGHTLY", "invalid FREQ"},
+		{"未知部分", "FREQ=DAILY;FOO=1", "FOO=1", "unknown rule part"},
+		{"无效 BYDAY", "FREQ=MONTHLY;BYDAY=9XX", "BYDAY=9XX", "invalid BYDAY"},
+		{"超出范围", "FREQ=DAILY;BYHOUR=24", "BYHOUR=24", "invalid BYHOUR"},
+		{"COUNT 和 UNTIL", "FREQ=DAILY;COUNT=1;UNTIL=19971224T000000Z", "FREQ=DAILY;COUNT=1;UNTIL=19971224T000000Z", "COUNT and UNTIL are mutually exclusive"},
+		{"每周不能有 BYMONTHDAY", "FREQ=WEEKLY;BYMONTHDAY=1", "FREQ=WEEKLY;BYMONTHDAY=1", "BYMONTHDAY is not allowed with FREQ=WEEKLY"},
+		{"每天不能有序号", "FREQ=DAILY;BYDAY=1MO", "FREQ=DAILY;BYDAY=1MO", "BYDAY with ordinal is only allowed with FREQ=MONTHLY or FREQ=YEARLY"},
+		{"无效 DTSTART", "DTSTART:1997-09-02\nRRULE:FREQ=DAILY", "1997-09-02", "invalid DTSTART"},
+		{"未知属性", "RRULE:FREQ=DAILY\nSUMMARY:x", "SUMMARY", "unknown property"},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := ParseRRule(p.spec)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("err: %v", err)
+			}
+			if pe.Token != p.token || pe.Reason != p.reason {
+				t.Errorf("token: %q, reason: %q", pe.Token, pe.Reason)
+			}
+		})
+	}
+}
+
+func Test_RRuleScheduleString(t *testing.T) {
+	spec := "DTSTART;TZID=America/New_York:19970902T090000\r\n" +
+		"RRULE:freq=monthly;bymonthday=13;byday=FR;until=20001231T000000Z\r\n" +
+		"EXDATE;TZID=America/New_York:19980213T090000\r\n" +
+		"RDATE:19970903T130000Z"
+	s, err := ParseRRule(spec)
+	if err != nil {
+		t.Skip(err)
+	}
+	want := "DTSTART;TZID=America/New_York:19970902T090000\n" +
+		"RRULE:FREQ=MONTHLY;UNTIL=20001231T000000Z;BYMONTHDAY=13;BYDAY=FR\n" +
+		"EXDATE;TZID=America/New_York:19980213T090000\n" +
+		"RDATE;TZID=America/New_York:19970903T090000"
+	if get := s.String(); get != want {
+		t.Fatalf("want: %s, get: %s", want, get)
+	}
+
+	var c struct {
+		Schedule Expr `json:"schedule"`
+	}
+	c.Schedule.Scheduler = s
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Schedule.Scheduler = nil
+	if err := json.Unmarshal(b, &c); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := c.Schedule.Scheduler.(*RRuleSchedule)
+	if !ok || r.String() != want {
+		t.Errorf("get: %v", c.Schedule.Scheduler)
+	}
+}
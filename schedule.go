@@ -18,8 +18,12 @@ const (
 	RangeDay     = 0xFFFFFFFE
 	RangeMonth   = 0x1FFE
 	RangeWeekDay = 0x7F
+	RangeYear    = 0xFFFFFFFFFFFFFFFF
 )
 
+// StartYear 年字段的起始年份，年字段取值范围为 StartYear 到 StartYear+63
+const StartYear = 2019
+
 // Scheduler 时间调度器，根据当前时间返回下一个符合定义的时间
 type Scheduler interface {
 	Laster
@@ -30,9 +34,27 @@ type Scheduler interface {
 
 // TimeSchedule 时间调度器
 type TimeSchedule struct {
-	last
 	// 秒、分、小时、日期、月份、星期、年
-	second, min, hour, day, month, weekDay uint64
+	second, min, hour, day, month, weekDay, year uint64
+
+	// 日期的特殊规则
+	// lastDay: bit n 表示每月倒数第 n+1 天(L、L-n)
+	// nearestWeekday: bit n 表示离每月 n 日最近的工作日(nW)
+	// lastWeekday: 每月最后一个工作日(LW)
+	lastDay, nearestWeekday uint64
+	lastWeekday             bool
+
+	// 星期的特殊规则
+	// nthWeekDay[n]: bit k 表示每月第 k 个星期 n(n#k)
+	// lastWeekDay: bit n 表示每月最后一个星期 n(nL)
+	nthWeekDay  [7]uint8
+	lastWeekDay uint64
+
+	// 日期和星期满足其一即可，为 false 时需要同时满足
+	dayOr bool
+
+	// 夏令时切换时的执行策略
+	dst DSTPolicy
 
 	loc *time.Location
 }
@@ -43,12 +65,22 @@ type Laster interface {
 	Last() time.Time
 }
 
-// last Laster 空实现
-type last struct{}
+// Prever 可以直接计算上一次执行时间的调度器，Prev 会优先使用
+type Prever interface {
+	// Prev 早于 t 的上一次执行时间，没有时返回零时
+	Prev(t time.Time) time.Time
+}
 
-// Last 调度器最后一次执行时间
-func (last) Last() time.Time {
-	return time.Time{}
+// Prev 调度器早于 t 的上一次执行时间，没有时返回零时
+// 调度器实现了 Prever 时直接调用，否则通过 Next 向前查找
+func Prev(s Scheduler, t time.Time) time.Time {
+	if e, ok := s.(Expr); ok {
+		s = e.Scheduler
+	}
+	if p, ok := s.(Prever); ok {
+		return p.Prev(t)
+	}
+	return prev(s, t)
 }
 
 // findBit 从低位向高位查找直到指为 1 的 bit 位(0-63)
@@ -62,142 +94,324 @@ func findBit(n, start, end uint64) uint64 {
 	return start
 }
 
-// findBitBack 从高位向低位查找直到指为 1 的 bit 位(0-63)
+// findBitBack 从高位向低位查找直到指为 1 的 bit 位(0-63), 没有找到时返回 64
 func findBitBack(n, start, end uint64) uint64 {
-	for start > end-1 {
-		if (n & (1 << start)) > 0 {
-			break
+	for i := start; i >= end && i <= start; i-- {
+		if (n & (1 << i)) > 0 {
+			return i
 		}
-		start--
 	}
-	return start
+	return 64
 }
 
 // Next 符合 TimeSchedule 的下个时间
 func (t *TimeSchedule) Next(_time time.Time) time.Time {
 	// 时间进1到秒
-	_time = _time.Add(1*time.Second - time.Duration(_time.Nanosecond())*time.Nanosecond)
+	next := _time.Add(1*time.Second - time.Duration(_time.Nanosecond())*time.Nanosecond)
 
 	// 原始时区
 	oriLoc := _time.Location()
 
-	// 统一时区
-	_time = _time.In(t.loc)
+	// 统一时区，时钟拨回时晚于 _time 的时刻可能对应更早的本地时间，需要从重复的本地时间开始查找
+	next = next.In(t.loc).Add(-t.fallBack(next))
 
-	year, month, day := _time.Date()
-	hour, min, sec := _time.Clock()
+	year, month, day := next.Date()
+	hour, min, sec := next.Clock()
 
-	look := func(_t time.Time) (ts time.Time, b bool) {
+	for end := t.endYear(year); year <= end; year, month, day, hour, min, sec = year+1, 1, 1, 0, 0, 0 {
+		if !t.matchYear(year) {
+			continue
+		}
 
 		// 找到符合要求的月
-		m := findBit(t.month, uint64(month), 12)
-		if m > 12 {
-			year++
-			month = 1
-			day = 1
-			hour = 0
-			min = 0
-			sec = 0
-			return time.Date(year, 1, 1, 0, 0, 0, 0, _t.Location()), false
-		}
-		if m != uint64(month) {
-			day = 1
-			hour = 0
-			min = 0
-			sec = 0
-		}
+		for ; month <= 12; month, day, hour, min, sec = month+1, 1, 0, 0, 0 {
+			if t.month&(1<<uint64(month)) == 0 {
+				continue
+			}
 
-		month = time.Month(m)
-
-		// 找到符合要求的天
-		for d := uint16(day); d < 33; d++ {
-			if (t.day & (1 << d)) == 0 {
-				if d == 32 {
-					month++
-					day = 1
-					hour = 0
-					min = 0
-					sec = 0
-					return time.Date(year, month, 1, 0, 0, 0, 0, _t.Location()), false
+			// 找到符合要求的天
+			for ; day <= daysIn(year, month); day, hour, min, sec = day+1, 0, 0, 0 {
+				if !t.matchDay(year, month, day) {
+					continue
+				}
+
+				// 找到符合要求的时、分、秒
+				if r := t.nextInDay(_time, year, month, day, hour, min, sec); !r.IsZero() {
+					return r.In(oriLoc)
 				}
-				continue
 			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// Prev 符合 TimeSchedule 的上一个时间(早于 _time)，没有时返回零时
+func (t *TimeSchedule) Prev(_time time.Time) time.Time {
+	// 时间退1到秒
+	prev := _time.Add(-time.Nanosecond)
+	prev = prev.Add(-time.Duration(prev.Nanosecond()) * time.Nanosecond)
+
+	// 原始时区
+	oriLoc := _time.Location()
+
+	// 统一时区，时钟拨回时早于 _time 的时刻可能对应更晚的本地时间，需要从重复的本地时间结束查找
+	prev = prev.In(t.loc).Add(t.fallBack(prev))
 
-			// 验证新生成的时间是否跨月、跨年, 检测星期是否符合
-			_t := time.Date(year, month, int(d), 0, 0, 0, 0, _t.Location())
-			_year, _month, _day := _t.Date()
-			if _year != year || _month != month || _day != int(d) || (1<<uint64(_t.Weekday()))&t.weekDay == 0 {
+	year, month, day := prev.Date()
+	hour, min, sec := prev.Clock()
+
+	for start := t.startYear(year); year >= start; year, month, day, hour, min, sec = year-1, 12, 31, 23, 59, 59 {
+		if !t.matchYear(year) {
+			continue
+		}
+
+		// 找到符合要求的月
+		for ; month >= 1; month, day, hour, min, sec = month-1, daysIn(year, month-1), 23, 59, 59 {
+			if t.month&(1<<uint64(month)) == 0 {
 				continue
 			}
 
-			if d != uint16(day) {
-				hour = 0
-				min = 0
-				sec = 0
-			}
+			// 找到符合要求的天
+			for ; day >= 1; day, hour, min, sec = day-1, 23, 59, 59 {
+				if !t.matchDay(year, month, day) {
+					continue
+				}
 
-			day = int(d)
-			break
+				// 找到符合要求的时、分、秒
+				if r := t.prevInDay(_time, year, month, day, hour, min, sec); !r.IsZero() {
+					return r.In(oriLoc)
+				}
+			}
 		}
+	}
 
-		// 找到符合要求的时
-		h := findBit(t.hour, uint64(hour), 23)
-		if h > 23 {
-			day++
-			hour = 0
-			min = 0
-			sec = 0
-			return time.Date(year, month, day, 0, 0, 0, 0, _t.Location()), false
-		}
+	return time.Time{}
+}
+
+// Last 最后一次执行时间，没有限制年份时返回零时
+func (t *TimeSchedule) Last() time.Time {
+	if t.year == RangeYear || t.year == 0 {
+		return time.Time{}
+	}
+
+	y := findBitBack(t.year, 63, 0)
+	return t.Prev(time.Date(StartYear+int(y)+1, 1, 1, 0, 0, 0, 0, t.loc))
+}
+
+// searchYears 没有限制年份时，向后查找的最大年数
+const searchYears = 50
+
+// endYear 从 year 开始查找时，最多查找到的年份
+func (t *TimeSchedule) endYear(year int) int {
+	if t.year == RangeYear {
+		return year + searchYears
+	}
+	return StartYear + 63
+}
+
+// startYear 从 year 开始向前查找时，最多查找到的年份
+func (t *TimeSchedule) startYear(year int) int {
+	if t.year == RangeYear {
+		return year - searchYears
+	}
+	return StartYear
+}
+
+// matchYear 年份是否符合要求
+func (t *TimeSchedule) matchYear(year int) bool {
+	if t.year == RangeYear {
+		return true
+	}
+	if year < StartYear || year > StartYear+63 {
+		return false
+	}
+	return t.year&(1<<uint64(year-StartYear)) > 0
+}
+
+// matchDay 日期和星期是否符合要求
+func (t *TimeSchedule) matchDay(year int, month time.Month, day int) bool {
+	if t.dayOr {
+		return t.matchMonthDay(year, month, day) || t.matchWeekDay(year, month, day)
+	}
+	return t.matchMonthDay(year, month, day) && t.matchWeekDay(year, month, day)
+}
+
+// matchMonthDay 日期是否符合要求
+func (t *TimeSchedule) matchMonthDay(year int, month time.Month, day int) bool {
+	if t.day&(1<<uint64(day)) > 0 {
+		return true
+	}
 
-		if h != uint64(hour) {
-			min = 0
-			sec = 0
+	last := daysIn(year, month)
+	if t.lastDay&(1<<uint64(last-day)) > 0 {
+		return true
+	}
+	if t.lastWeekday && day == nearestWorkday(year, month, last) {
+		return true
+	}
+	for n := findBit(t.nearestWeekday, 1, 31); n <= 31; n = findBit(t.nearestWeekday, n+1, 31) {
+		if int(n) <= last && day == nearestWorkday(year, month, int(n)) {
+			return true
 		}
+	}
+	return false
+}
+
+// matchWeekDay 星期是否符合要求
+func (t *TimeSchedule) matchWeekDay(year int, month time.Month, day int) bool {
+	weekDay := weekday(year, month, day)
+	if t.weekDay&(1<<uint64(weekDay)) > 0 {
+		return true
+	}
+	if t.nthWeekDay[weekDay]&(1<<uint64((day-1)/7+1)) > 0 {
+		return true
+	}
+	return t.lastWeekDay&(1<<uint64(weekDay)) > 0 && day+7 > daysIn(year, month)
+}
 
-		hour = int(h)
+// weekday 某年某月某日是星期几
+func weekday(year int, month time.Month, day int) time.Weekday {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+}
 
-		// 找到符合要求的分
-		mm := findBit(t.min, uint64(min), 59)
-		if mm > 59 {
-			hour++
-			min = 0
-			sec = 0
-			return time.Date(year, month, day, hour, 0, 0, 0, _t.Location()), false
+// nearestWorkday 离某月 day 日最近的工作日，不会跨月
+func nearestWorkday(year int, month time.Month, day int) int {
+	switch weekday(year, month, day) {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
 		}
+		return day - 1
+	case time.Sunday:
+		if day == daysIn(year, month) {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
 
-		if mm != uint64(min) {
-			sec = 0
+// nextInDay 某天从 hour:min:sec 起晚于 _time 的第一个执行时刻，没有时返回零时
+// 时钟拨回且两次都执行时，重复时间的第二个时刻可能晚于之后的时间，需要比较当天剩余的全部时刻
+func (t *TimeSchedule) nextInDay(_time time.Time, year int, month time.Month, day, hour, min, sec int) time.Time {
+	var res time.Time
+	overlap := false
+	for h, m, s, ok := t.nextClock(hour, min, sec); ok; h, m, s, ok = t.nextClock(h, m, s+1) {
+		first, second := t.instants(year, month, day, h, m, s)
+		overlap = overlap || !second.IsZero()
+		for _, r := range [...]time.Time{first, second} {
+			if !r.IsZero() && r.After(_time) && (res.IsZero() || r.Before(res)) {
+				res = r
+			}
 		}
+		if !res.IsZero() && !overlap {
+			break
+		}
+	}
+	return res
+}
 
-		min = int(mm)
+// prevInDay 某天从 hour:min:sec 起向前查找早于 _time 的第一个执行时刻，没有时返回零时
+func (t *TimeSchedule) prevInDay(_time time.Time, year int, month time.Month, day, hour, min, sec int) time.Time {
+	var res time.Time
+	overlap := false
+	for h, m, s, ok := t.prevClock(hour, min, sec); ok; h, m, s, ok = t.prevClock(h, m, s-1) {
+		first, second := t.instants(year, month, day, h, m, s)
+		overlap = overlap || !second.IsZero()
+		for _, r := range [...]time.Time{first, second} {
+			if !r.IsZero() && r.Before(_time) && (res.IsZero() || r.After(res)) {
+				res = r
+			}
+		}
+		if !res.IsZero() && !overlap {
+			break
+		}
+	}
+	return res
+}
 
-		// 找到符合要求的秒
-		s := findBit(t.second, uint64(sec), 59)
-		if s > 59 {
-			min++
-			sec = 0
-			return time.Date(year, month, day, hour, min, 0, 0, _t.Location()), false
+// nextClock 查找不早于 hour:min:sec 且符合要求的时、分、秒
+func (t *TimeSchedule) nextClock(hour, min, sec int) (int, int, int, bool) {
+	for h := findBit(t.hour, uint64(hour), 23); h <= 23; h = findBit(t.hour, h+1, 23) {
+		if int(h) != hour {
+			min, sec = 0, 0
 		}
-		sec = int(s)
+		for m := findBit(t.min, uint64(min), 59); m <= 59; m = findBit(t.min, m+1, 59) {
+			if int(m) != min {
+				sec = 0
+			}
+			if s := findBit(t.second, uint64(sec), 59); s <= 59 {
+				return int(h), int(m), int(s), true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
 
-		return time.Date(year, month, day, hour, min, sec, 0, _t.Location()), true
+// prevClock 查找不晚于 hour:min:sec 且符合要求的时、分、秒
+func (t *TimeSchedule) prevClock(hour, min, sec int) (int, int, int, bool) {
+	// 从 start 向低位查找，start 小于 0 时表示没有找到
+	back := func(n uint64, start int) int {
+		if start < 0 {
+			return 64
+		}
+		return int(findBitBack(n, uint64(start), 0))
 	}
 
-	for {
-		var b bool
-		_time, b = look(_time)
-		if b {
-			return _time.In(oriLoc)
+	for h := back(t.hour, hour); h <= 23; h = back(t.hour, h-1) {
+		if h != hour {
+			min, sec = 59, 59
 		}
-		if _time.IsZero() {
-			return _time
+		for m := back(t.min, min); m <= 59; m = back(t.min, m-1) {
+			if m != min {
+				sec = 59
+			}
+			if s := back(t.second, sec); s <= 59 {
+				return h, m, s, true
+			}
 		}
+	}
+	return 0, 0, 0, false
+}
+
+// daysIn 某年某月的天数
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
 
-		if _time.Year()-year > 5 {
+// maxPrevSearch 向前查找执行时间的最大范围
+const maxPrevSearch = 200 * 365 * 24 * time.Hour
+
+// prev 早于 t 的最后一次执行时间，没有找到时返回零时
+// 先按倍增的步长向前确定查找区间，再用 Next 二分查找
+func prev(s Scheduler, t time.Time) time.Time {
+	before := func(from time.Time) bool {
+		n := s.Next(from)
+		return !n.IsZero() && n.Before(t)
+	}
+
+	lo, hi := t, t
+	for w := time.Second; ; w *= 2 {
+		if w > maxPrevSearch {
 			return time.Time{}
 		}
+		lo = t.Add(-w)
+		if before(lo) {
+			break
+		}
+		hi = lo
+	}
+
+	for hi.Sub(lo) > time.Nanosecond {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if before(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
 	}
+	return s.Next(lo)
 }
 
 // ts 无效数据修正
@@ -221,6 +435,8 @@ func Time2TimeSchedule(_time time.Time) *TimeSchedule {
 	t.day = 1 << uint64(day)
 	t.month = 1 << uint64(mon)
 	t.weekDay = 1 << uint64(_time.Weekday())
+	t.year = RangeYear
+	t.loc = _time.Location()
 	return t
 }
 
@@ -257,23 +473,47 @@ func bitSet(b, index, val uint64) uint64 {
 
 // DurationSchedule 时间调度器(定长时间执行,比如每72小时执行一次)
 type DurationSchedule struct {
-	last
-
 	// 起始时间
 	start time.Time
 
 	// 间隔执行时间
 	frequency time.Duration
+
+	// 执行次数，为 0 时没有限制
+	count int
 }
 
-// Next 临近 t 的下一次执行时机
+// Next 临近 t 的下一次执行时机(晚于 t)
+// 执行时机为 start, start+frequency, start+2*frequency...
 func (d *DurationSchedule) Next(t time.Time) time.Time {
-	dur := t.Sub(d.start)
-	if quo := dur % d.frequency; quo != 0 {
-		return d.start.Add((dur/d.frequency + 1) * d.frequency)
+	if t.Before(d.start) {
+		return d.start
+	}
+	n := t.Sub(d.start)/d.frequency + 1
+	if d.count > 0 && n >= time.Duration(d.count) {
+		return time.Time{}
+	}
+	return d.start.Add(n * d.frequency)
+}
 
+// Prev 早于 t 的上一次执行时机，没有时返回零时
+func (d *DurationSchedule) Prev(t time.Time) time.Time {
+	if !t.After(d.start) {
+		return time.Time{}
 	}
-	return t
+	n := (t.Sub(d.start) - 1) / d.frequency
+	if d.count > 0 && n >= time.Duration(d.count) {
+		n = time.Duration(d.count) - 1
+	}
+	return d.start.Add(n * d.frequency)
+}
+
+// Last 最后一次执行时间，没有限制执行次数时返回零时
+func (d *DurationSchedule) Last() time.Time {
+	if d.count <= 0 {
+		return time.Time{}
+	}
+	return d.start.Add(time.Duration(d.count-1) * d.frequency)
 }
 
 var _ Scheduler = new(DurationSchedule)
@@ -288,6 +528,14 @@ func (f *FixSchedule) Next(t time.Time) time.Time {
 	return f.rTime
 }
 
+// Prev 早于 t 时返回固定的执行时间，否则返回零时
+func (f *FixSchedule) Prev(t time.Time) time.Time {
+	if f.rTime.Before(t) {
+		return f.rTime
+	}
+	return time.Time{}
+}
+
 // Last 最后一次执行时间
 func (f *FixSchedule) Last() time.Time {
 	return f.rTime
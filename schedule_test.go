@@ -1,17 +1,10 @@
 package corn
 
 import (
-	"fmt"
 	"testing"
 	"time"
 )
 
-// String 格式化输出
-func (t *TimeSchedule) String() string {
-	return fmt.Sprintf("{second: %x, min: %x, hour: %x, day: %x, month: %x, weekDay: %x}",
-		t.second, t.min, t.hour, t.day, t.month, t.weekDay)
-}
-
 func Test_Time2TimeSchedule(t *testing.T) {
 	type paramTime2TS struct {
 		name  string
@@ -20,8 +13,8 @@ func Test_Time2TimeSchedule(t *testing.T) {
 	}
 
 	data := []paramTime2TS{
-		{"测试空时间", time.Time{}, TimeSchedule{last{}, 1, 1, 1, 2, 2, 2, time.Local}},
-		{"测试空的月份", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), TimeSchedule{last{}, 1, 1, 1, 1 << 20, 1 << 5, 1 << 1, time.Local}},
+		{"测试空时间", time.Time{}, TimeSchedule{second: 1, min: 1, hour: 1, day: 2, month: 2, weekDay: 2, loc: time.Local}},
+		{"测试空的月份", time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local), TimeSchedule{second: 1, min: 1, hour: 1, day: 1 << 20, month: 1 << 5, weekDay: 1 << 1, loc: time.Local}},
 	}
 
 	for _, p := range data {
@@ -52,13 +45,41 @@ func Test_TimeScheduleNext(t *testing.T) {
 		{"测试跨分", "0 20 5 28,31 * *", time.Date(2019, 2, 28, 5, 19, 0, 0, time.Local), time.Date(2019, 2, 28, 5, 20, 0, 0, time.Local)},
 		{"测试跨秒", "0 20 5 28,31 * *", time.Date(2019, 2, 28, 5, 19, 23, 0, time.Local), time.Date(2019, 2, 28, 5, 20, 0, 0, time.Local)},
 		{"测试星期天", "* * 3 * * 0", time.Date(2019, 11, 20, 1, 19, 23, 0, time.Local), time.Date(2019, 11, 24, 3, 0, 0, 0, time.Local)},
-		{"测试分", "*/5 10 * * *", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Date(2019, 2, 28, 5, 10, 25, 0, time.Local)},
-		{"测试无符合条件的时间", "* * * 32 3", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Time{}},
+		{"测试分", "*/5 10 * * * *", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Date(2019, 2, 28, 5, 10, 25, 0, time.Local)},
+		{"测试无符合条件的时间", "0 0 0 30 2 *", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Time{}},
+		{"测试闰年", "0 0 0 29 2 1", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Date(2044, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"测试年份", "0 0 9 1 1 * 2027-2029", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Date(2027, 1, 1, 9, 0, 0, 0, time.Local)},
+		{"测试年份内", "0 0 9 1 1 * 2027-2029", time.Date(2027, 1, 1, 9, 0, 0, 0, time.Local), time.Date(2028, 1, 1, 9, 0, 0, 0, time.Local)},
+		{"测试超出年份", "0 0 9 1 1 * 2027-2029", time.Date(2029, 1, 1, 9, 0, 0, 0, time.Local), time.Time{}},
+		{"测试每月最后一天", "0 0 18 L * ?", time.Date(2019, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 2, 28, 18, 0, 0, 0, time.Local)},
+		{"测试闰年最后一天", "0 0 18 L * ?", time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2020, 2, 29, 18, 0, 0, 0, time.Local)},
+		{"测试倒数第三天", "0 0 18 L-2 * ?", time.Date(2019, 4, 29, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 29, 18, 0, 0, 0, time.Local)},
+		{"测试最后一个工作日", "0 0 18 LW * ?", time.Date(2019, 8, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 8, 30, 18, 0, 0, 0, time.Local)},
+		{"测试最近工作日-周六", "0 0 9 15W * ?", time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 6, 14, 9, 0, 0, 0, time.Local)},
+		{"测试最近工作日-周日", "0 0 9 15W * ?", time.Date(2019, 9, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 9, 16, 9, 0, 0, 0, time.Local)},
+		{"测试最近工作日-不跨月", "0 0 9 1W * ?", time.Date(2019, 5, 2, 0, 0, 0, 0, time.Local), time.Date(2019, 6, 3, 9, 0, 0, 0, time.Local)},
+		{"测试最近工作日-月末不跨月", "0 0 9 30W * ?", time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 6, 28, 9, 0, 0, 0, time.Local)},
+		{"测试最近工作日-跳过小月", "0 0 9 31W * ?", time.Date(2019, 4, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 31, 9, 0, 0, 0, time.Local)},
+		{"测试第二个周二", "0 0 9 ? * 2#2", time.Date(2019, 5, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 14, 9, 0, 0, 0, time.Local)},
+		{"测试第五个周五", "0 0 9 ? * FRI#5", time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 8, 30, 9, 0, 0, 0, time.Local)},
+		{"测试最后一个周五", "0 0 9 ? * 5L", time.Date(2019, 5, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 31, 9, 0, 0, 0, time.Local)},
+		{"测试最后一个周一", "0 0 9 ? * MONL", time.Date(2019, 5, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 27, 9, 0, 0, 0, time.Local)},
+		{"测试星期 L", "0 0 9 ? * L", time.Date(2019, 5, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 4, 9, 0, 0, 0, time.Local)},
+		{"测试 L 与日期组合", "0 0 9 1,L * ?", time.Date(2019, 5, 2, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 31, 9, 0, 0, 0, time.Local)},
+		{"测试起始间隔", "0/30 * * * * *", time.Date(2019, 2, 28, 5, 10, 23, 0, time.Local), time.Date(2019, 2, 28, 5, 10, 30, 0, time.Local)},
+		{"测试起始间隔跨分", "0/30 * * * * *", time.Date(2019, 2, 28, 5, 10, 30, 0, time.Local), time.Date(2019, 2, 28, 5, 11, 0, 0, time.Local)},
+		{"测试跨周期小时", "0 0 22-2 * * *", time.Date(2019, 2, 28, 23, 10, 0, 0, time.Local), time.Date(2019, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"测试跨周期星期", "0 0 9 * * FRI-MON", time.Date(2019, 5, 21, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 24, 9, 0, 0, 0, time.Local)},
+		{"测试年份起始间隔", "0 0 9 1 1 * 2020/5", time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 1, 1, 9, 0, 0, 0, time.Local)},
+		{"测试年份间隔", "0 0 9 1 1 * 2020-2030/5", time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 1, 1, 9, 0, 0, 0, time.Local)},
 	}
 
 	for _, p := range data {
 		t.Run(p.name, func(t *testing.T) {
-			ts, _ := Parse(p.expr)
+			ts, err := Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if get := ts.Next(p.now); !get.Equal(p.next) {
 				t.Errorf("ts: %v, want: %s, get: %s", ts, p.next.Format("2006-01-02 15:04:05"), get.Format("2006-01-02 15:04:05"))
 			}
@@ -74,14 +95,18 @@ func Test_TimeScheduleMax(t *testing.T) {
 	}
 
 	data := []paramTime2TS{
-		{"执行一次", "5 4 15 2 1 *", time.Date(2019, 1, 2, 15, 4, 5, 0, time.Local)},
+		{"执行一次", "5 4 15 2 1 *", time.Time{}},
+		{"限制年份", "5 4 15 2 1 * 2027-2029", time.Date(2029, 1, 2, 15, 4, 5, 0, time.Local)},
+		{"限制年份及星期", "5 4 15 * * MON 2027", time.Date(2027, 12, 27, 15, 4, 5, 0, time.Local)},
+		{"每秒执行", "* * * * * * 2030", time.Date(2030, 12, 31, 23, 59, 59, 0, time.Local)},
+		{"无符合条件的时间", "0 0 0 30 2 * 2030", time.Time{}},
 	}
 
 	for _, p := range data {
 		t.Run(p.name, func(t *testing.T) {
 			ts, _ := Parse(p.expr)
-			if get := ts.Last(); !get.IsZero() {
-				t.Errorf("ts: %v, get: %s", ts, get.Format("2006-01-02 15:04:05"))
+			if get := ts.Last(); !get.Equal(p.max) {
+				t.Errorf("ts: %v, want: %s, get: %s", ts, p.max.Format("2006-01-02 15:04:05"), get.Format("2006-01-02 15:04:05"))
 			}
 		})
 	}
@@ -89,12 +114,12 @@ func Test_TimeScheduleMax(t *testing.T) {
 
 func Benchmark_Range(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		parse("0-31/4")
+		parse("0-31/4", days)
 	}
 }
 func Benchmark_Parse(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		Parse("0-31/4,40-50/2 20 15 * *")
+		Parse("0-31/4,40-50/2 20 15 * * *")
 	}
 }
 
@@ -105,3 +130,122 @@ func Benchmark_Next(b *testing.B) {
 		ts.Next(now)
 	}
 }
+
+func Test_DurationScheduleNext(t *testing.T) {
+	start := time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local)
+	ds := &DurationSchedule{start: start, frequency: time.Hour}
+
+	data := []struct {
+		name string
+		now  time.Time
+		next time.Time
+	}{
+		{"起始时间之前", start.Add(-90 * time.Minute), start},
+		{"起始时间", start, start.Add(time.Hour)},
+		{"两次执行之间", start.Add(90 * time.Minute), start.Add(2 * time.Hour)},
+		{"恰好执行时间", start.Add(2 * time.Hour), start.Add(3 * time.Hour)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			if get := ds.Next(p.now); !get.Equal(p.next) {
+				t.Errorf("want: %s, get: %s", p.next, get)
+			}
+		})
+	}
+}
+
+func Test_TimeSchedulePrev(t *testing.T) {
+	data := []struct {
+		name string
+		expr string
+		now  time.Time
+		prev time.Time
+	}{
+		{"当天", "0 20 5 * * *", time.Date(2019, 5, 20, 6, 0, 0, 0, time.Local), time.Date(2019, 5, 20, 5, 20, 0, 0, time.Local)},
+		{"恰好执行时间", "0 20 5 * * *", time.Date(2019, 5, 20, 5, 20, 0, 0, time.Local), time.Date(2019, 5, 19, 5, 20, 0, 0, time.Local)},
+		{"纳秒", "* * * * * *", time.Date(2019, 5, 20, 5, 20, 0, 1, time.Local), time.Date(2019, 5, 20, 5, 20, 0, 0, time.Local)},
+		{"跨年", "0 0 12 31 12 *", time.Date(2020, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 12, 31, 12, 0, 0, 0, time.Local)},
+		{"2 月 29 日", "0 0 0 29 2 *", time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2020, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"月末", "0 0 8 L * ?", time.Date(2019, 3, 15, 0, 0, 0, 0, time.Local), time.Date(2019, 2, 28, 8, 0, 0, 0, time.Local)},
+		{"最后一个星期五", "0 0 0 ? * 5L", time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2019, 5, 31, 0, 0, 0, 0, time.Local)},
+		{"限制年份", "0 0 0 1 1 * 2019", time.Date(2018, 1, 1, 0, 0, 0, 0, time.Local), time.Time{}},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			ts, err := Parse(p.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if get := Prev(ts, p.now); !get.Equal(p.prev) {
+				t.Errorf("want: %s, get: %s", p.prev, get)
+			}
+		})
+	}
+}
+
+func Test_TimeSchedulePrevEquivalent(t *testing.T) {
+	exprs := []string{
+		"*/7 */13 * * * *",
+		"0 30 9 * * 1-5",
+		"CRON_TZ=America/New_York 0 30 2 * * *",
+		"CRON_TZ=Europe/London 0 0 1 * 3,10 0L",
+		"0 0 0 15W,LW * ?",
+		"0 0 0 ? * 2#2",
+		"CRON_DAY_OR=1 0 0 12 1 * 1",
+	}
+	now := time.Date(2019, 11, 3, 12, 0, 0, 0, time.UTC)
+
+	for _, expr := range exprs {
+		t.Run(expr, func(t *testing.T) {
+			s, err := Parse(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 向前查找结果与通过 Next 二分查找的结果相同
+			for i, want := 0, now; i < 30; i++ {
+				get := s.(*TimeSchedule).Prev(want)
+				if want = prev(s, want); !get.Equal(want) {
+					t.Fatalf("want: %s, get: %s", want, get)
+				}
+			}
+		})
+	}
+}
+
+func Test_DurationSchedulePrev(t *testing.T) {
+	start := time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local)
+	ds := &DurationSchedule{start: start, frequency: time.Hour, count: 3}
+
+	data := []struct {
+		name string
+		now  time.Time
+		prev time.Time
+	}{
+		{"起始时间之前", start.Add(-90 * time.Minute), time.Time{}},
+		{"起始时间", start, time.Time{}},
+		{"两次执行之间", start.Add(90 * time.Minute), start.Add(time.Hour)},
+		{"恰好执行时间", start.Add(2 * time.Hour), start.Add(time.Hour)},
+		{"执行结束之后", start.Add(24 * time.Hour), start.Add(2 * time.Hour)},
+	}
+
+	for _, p := range data {
+		t.Run(p.name, func(t *testing.T) {
+			if get := Prev(ds, p.now); !get.Equal(p.prev) {
+				t.Errorf("want: %s, get: %s", p.prev, get)
+			}
+		})
+	}
+}
+
+func Test_FixSchedulePrev(t *testing.T) {
+	fix := time.Date(2019, 5, 20, 0, 0, 0, 0, time.Local)
+	fs := &FixSchedule{fix}
+	if get := Prev(fs, fix); !get.IsZero() {
+		t.Errorf("want: 零时, get: %s", get)
+	}
+	if get := Prev(Expr{fs}, fix.Add(time.Second)); !get.Equal(fix) {
+		t.Errorf("want: %s, get: %s", fix, get)
+	}
+}